@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"os"
+
+	"rtscommander/m/internal/backup"
+	"rtscommander/m/internal/config"
+	"rtscommander/m/pkg/client"
+)
+
+// backupKeys lit (ou crée) la clé de signature des sauvegardes
+func backupKeys(keyPath, passphrase string) backup.Keys {
+	key, err := backup.LoadKey(keyPath)
+	if err != nil {
+		log.Fatalf("Failed to load backup key: %v", err)
+	}
+	return backup.Keys{SigningKey: key, Passphrase: passphrase}
+}
+
+// exportBackup écrit la configuration dans une archive
+func exportBackup(cfg *config.Config, path string, keys backup.Keys) {
+	payload, err := cfg.Export()
+	if err != nil {
+		log.Fatalf("Failed to export config: %v", err)
+	}
+	data, err := backup.Seal(payload, keys)
+	if err != nil {
+		log.Fatalf("Failed to create backup: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		log.Fatalf("Failed to write backup: %v", err)
+	}
+
+	fmt.Printf("Backup of %d remote(s) written to %s\n", len(cfg.ListRemotes()), path)
+	printBackupKeyHint(keys)
+}
+
+// importBackup restaure une archive dans la configuration
+func importBackup(cfg *config.Config, path string, keys backup.Keys, force bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatalf("Failed to read backup: %v", err)
+	}
+	payload, err := backup.Open(data, keys)
+	if err != nil {
+		log.Fatalf("Failed to open backup: %v", err)
+	}
+
+	report, err := cfg.Restore(payload, force)
+	if err != nil {
+		log.Fatalf("Backup not restored: %v", err)
+	}
+	printRestoreReport(path, report.Remotes, report.Groups, report.Schedules, len(report.Regressions))
+}
+
+// runRemoteBackup exporte ou restaure une archive via l'API d'un serveur
+func runRemoteBackup(server, token string, tlsConfig *tls.Config, exportPath, importPath, passphrase string, force bool) {
+	c := client.New(server, token).WithTLS(tlsConfig)
+	ctx := context.Background()
+
+	if exportPath != "" {
+		data, err := c.Backup(ctx, passphrase)
+		if err != nil {
+			log.Fatalf("Failed to export backup: %v", err)
+		}
+		if err := os.WriteFile(exportPath, data, 0o600); err != nil {
+			log.Fatalf("Failed to write backup: %v", err)
+		}
+		fmt.Printf("Backup of %s written to %s\n", server, exportPath)
+		return
+	}
+
+	data, err := os.ReadFile(importPath)
+	if err != nil {
+		log.Fatalf("Failed to read backup: %v", err)
+	}
+	report, err := c.Restore(ctx, data, passphrase, force)
+	if err != nil {
+		log.Fatalf("Backup not restored: %v", err)
+	}
+	printRestoreReport(importPath, report.Remotes, report.Groups, report.Schedules, len(report.Regressions))
+}
+
+// printRestoreReport résume une restauration
+func printRestoreReport(path string, remotes, groups, schedules, regressions int) {
+	fmt.Printf("Restored %s: %d remote(s), %d group(s), %d schedule(s)\n", path, remotes, groups, schedules)
+	if regressions > 0 {
+		fmt.Printf("Warning: %d rolling code(s) went backwards (--force), the motors may ignore their next commands\n", regressions)
+	}
+}
+
+// printBackupKeyHint rappelle le secret nécessaire à la restauration
+func printBackupKeyHint(keys backup.Keys) {
+	if keys.Passphrase != "" {
+		fmt.Println("Encrypted: the passphrase is required to restore it")
+		return
+	}
+	fmt.Println("Signed with the backup key: keep a copy of it to restore on another device")
+}
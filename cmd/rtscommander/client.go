@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"rtscommander/m/internal/controller"
+	"rtscommander/m/pkg/client"
+)
+
+// runRemoteMode exécute --list ou une commande via l'API d'un serveur
+// rtsCommander plutôt qu'avec la radio locale. Retourne false si aucune de ces
+// actions n'est demandée.
+func runRemoteMode(server, token string, tlsConfig *tls.Config, list bool, remoteName, groupName, command string, value, repeats, holdMs int) bool {
+	c := client.New(server, token).WithTLS(tlsConfig)
+	ctx := context.Background()
+
+	if list {
+		remotes, err := c.ListRemotes(ctx)
+		if err != nil {
+			log.Fatalf("Failed to list remotes: %v", err)
+		}
+		fmt.Printf("Remotes on %s (%d):\n", server, len(remotes))
+		for _, r := range remotes {
+			if r.Redacted {
+				fmt.Printf("  - %s", r.Name) // Secrets d'appairage réservés à la portée admin
+			} else {
+				fmt.Printf("  - %s: address=0x%06X, rolling_code=%d", r.Name, r.Address, r.RollingCode)
+			}
+			if r.Position != nil {
+				fmt.Printf(", position=%d%%", *r.Position)
+			}
+			if r.Moving != "" {
+				fmt.Printf(" (moving %s)", r.Moving)
+			}
+			fmt.Println()
+		}
+
+		groups, err := c.ListGroups(ctx)
+		if err != nil {
+			log.Fatalf("Failed to list groups: %v", err)
+		}
+		if len(groups) > 0 {
+			fmt.Printf("Groups (%d):\n", len(groups))
+			for _, g := range groups {
+				fmt.Printf("  - %s: %s\n", g.Name, strings.Join(g.Members, ", "))
+			}
+		}
+		return true
+	}
+
+	if command == "" || (remoteName == "") == (groupName == "") {
+		return false
+	}
+
+	opts := client.CommandOptions{HoldMs: holdMs, Repeats: repeats}
+	if command == controller.PositionCommand {
+		opts.Value = &value
+	}
+
+	var resp *client.CommandResponse
+	var err error
+	if groupName != "" {
+		resp, err = c.SendGroupCommand(ctx, groupName, command, opts)
+	} else {
+		resp, err = c.SendCommand(ctx, remoteName, command, opts)
+	}
+
+	// Résultats par membre, y compris en cas d'échec partiel
+	var apiErr *client.Error
+	if errors.As(err, &apiErr) && apiErr.Response != nil {
+		resp = apiErr.Response
+	}
+	if resp != nil {
+		for _, r := range resp.Results {
+			if r.Success {
+				fmt.Printf("  ✓ %s\n", r.Remote)
+			} else {
+				fmt.Printf("  ✗ %s: %s\n", r.Remote, r.Error)
+			}
+		}
+	}
+	if err != nil {
+		log.Fatalf("Failed to send command: %v", err)
+	}
+
+	fmt.Println(resp.Message)
+	return true
+}
@@ -4,33 +4,100 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"math"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
 	"rtscommander/m/internal/api"
+	"rtscommander/m/internal/audit"
+	"rtscommander/m/internal/backup"
 	"rtscommander/m/internal/config"
 	"rtscommander/m/internal/controller"
+	"rtscommander/m/internal/mqtt"
+	"rtscommander/m/internal/pki"
 	"rtscommander/m/internal/radio"
 	"rtscommander/m/internal/remote"
+	"rtscommander/m/internal/scheduler"
+	"rtscommander/m/internal/solar"
+	"rtscommander/m/pkg/client"
 
 	"periph.io/x/host/v3"
 )
 
 func main() {
-	// Initialiser periph.io pour accéder au hardware
-	if _, err := host.Init(); err != nil {
-		log.Fatalf("Failed to initialize periph.io: %v", err)
-	}
-
 	// Flags CLI
 	configPath := flag.String("config", "remotes.json", "Path to the configuration file")
+	storeKind := flag.String("store", config.StoreJSON, "Configuration store: json, or bolt for an embedded database (remotes.db)")
 	httpAddr := flag.String("http", "", "HTTP server address (e.g., :8080)")
+	server := flag.String("server", "", "Send --cmd and --list through the API of a running server (e.g., http://raspberrypi:8080)")
+	token := flag.String("token", os.Getenv("RTS_TOKEN"), "API token for --server (default: $RTS_TOKEN)")
+	issueToken := flag.String("issue-token", "", "Issue an API token with this name (see --scopes)")
+	scopes := flag.String("scopes", "", "Comma-separated scopes for --issue-token: "+strings.Join(config.Scopes, ", "))
+	tokenRemotes := flag.String("token-remotes", "", "Comma-separated remotes the token issued by --issue-token may control (default: all)")
+	revokeToken := flag.String("revoke-token", "", "Revoke the API token with this name")
+	tlsCert := flag.String("tls-cert", "", "TLS certificate for --http (PEM, reloaded on SIGHUP)")
+	tlsKey := flag.String("tls-key", "", "TLS private key for --http (PEM)")
+	tlsClientCA := flag.String("tls-client-ca", "", "CA verifying client certificates (mutual TLS, the certificate name must match a token)")
+	tlsRequireClient := flag.Bool("tls-require-client-cert", false, "Reject HTTPS clients without a valid certificate (with --tls-client-ca)")
+	serverCA := flag.String("server-ca", "", "CA trusted for an https --server (e.g. pki/ca.pem)")
+	clientCert := flag.String("client-cert", "", "Client certificate presented to --server (mutual TLS)")
+	clientKey := flag.String("client-key", "", "Client private key for --client-cert")
+	initCA := flag.Bool("init-ca", false, "Create a local CA in --ca-dir and issue the server certificate for --tls-hosts")
+	caDir := flag.String("ca-dir", "pki", "Directory of the local CA")
+	tlsHosts := flag.String("tls-hosts", "", "Comma-separated host names and IPs of the server certificate (default: localhost and this host)")
+	issueClientCert := flag.String("issue-client-cert", "", "Issue a client certificate with this name from the CA in --ca-dir")
 	remoteName := flag.String("remote", "", "Remote control name")
-	command := flag.String("cmd", "", "Command to send: up, down, my/stop, prog")
+	command := flag.String("cmd", "", "Command to send: "+strings.Join(remote.CommandNames(), ", ")+", "+controller.PositionCommand)
 	addRemote := flag.Bool("add", false, "Add a new remote")
 	listRemotes := flag.Bool("list", false, "List all remotes")
 	testCC1101 := flag.Bool("test", false, "Test CC1101 module and SPI connection")
 	address := flag.Uint("address", 0, "Remote address (24-bit, required for -add)")
 	rollingCode := flag.Uint("rolling", 1, "Initial rolling code (for -add)")
 	encKey := flag.Uint("key", 0xA7, "Encryption key (for -add)")
+	travelUp := flag.Int("travel-up-ms", 0, "Full travel time upwards in ms, enables position tracking (for -add)")
+	travelDown := flag.Int("travel-down-ms", 0, "Full travel time downwards in ms, enables position tracking (for -add)")
+	value := flag.Int("value", -1, "Target position in percent (for --cmd position)")
+	holdMs := flag.Int("hold-ms", 0, "Hold the button for this many milliseconds (long press)")
+	repeats := flag.Int("repeats", 0, "Number of frame repetitions after the first one (overrides the command default)")
+	groupName := flag.String("group", "", "Group name (send a command to all members, or with --add-group/--delete-group)")
+	members := flag.String("members", "", "Comma-separated remote names (for --add-group)")
+	groupRemote := flag.String("group-remote", "", "Remote whose address is paired with every member motor (for --add-group)")
+	addGroup := flag.Bool("add-group", false, "Add or replace a group of remotes")
+	pairGroup := flag.Bool("pair-group", false, "Pair the group remote with each member motor (or only --remote)")
+	deleteGroup := flag.Bool("delete-group", false, "Delete a group")
+	schedule := flag.String("schedule", "", `Add a schedule, e.g. "weekdays 07:30 up salon" or "every day sunset+15min down group rdc"`)
+	scheduleName := flag.String("schedule-name", "", "Schedule name (for --schedule, generated if empty)")
+	deleteSchedule := flag.String("delete-schedule", "", "Delete the schedule with this name")
+	timezone := flag.String("timezone", "", "Set the timezone used by schedules (e.g. Europe/Paris)")
+	latitude := flag.Float64("latitude", math.NaN(), "Set the site latitude for sunrise/sunset schedules (degrees, north positive)")
+	longitude := flag.Float64("longitude", math.NaN(), "Set the site longitude for sunrise/sunset schedules (degrees, east positive)")
+	mqttBroker := flag.String("mqtt", "", "MQTT broker URL for Home Assistant integration (e.g. tcp://localhost:1883)")
+	mqttUser := flag.String("mqtt-user", "", "MQTT username")
+	mqttPassword := flag.String("mqtt-password", "", "MQTT password")
+	mqttTopic := flag.String("mqtt-topic", mqtt.DefaultTopic, "Base MQTT topic for states and commands")
+	mqttDiscovery := flag.String("mqtt-discovery-prefix", mqtt.DefaultDiscoveryPrefix, "Home Assistant MQTT discovery prefix")
+	mqttRemotes := flag.String("mqtt-remotes", "", "Comma-separated remotes exposed to MQTT (default: all)")
+	auditPath := flag.String("audit-log", "audit.jsonl", "Audit log of transmitted commands (JSON lines, empty to disable)")
+	auditMaxMB := flag.Int("audit-max-mb", audit.DefaultMaxSize>>20, "Size in MB at which the audit log is rotated")
+	auditFiles := flag.Int("audit-files", audit.DefaultMaxFiles, "Number of rotated audit log files kept")
+	auditMaxEntries := flag.Int("audit-max-entries", audit.DefaultMaxEntries, "Audit entries kept in the config database (with --store bolt)")
+	migrate := flag.Bool("migrate", false, "Migrate the configuration to the current schema version (a copy of the previous version is kept)")
+	dryRun := flag.Bool("dry-run", false, "With --migrate, only show what would change")
+	exportPath := flag.String("export", "", "Export remotes, rolling codes, groups and schedules to a signed backup archive")
+	importPath := flag.String("import", "", "Restore a backup archive (refused if a rolling code would go backwards, see --force)")
+	force := flag.Bool("force", false, "With --import, restore even if rolling codes go backwards")
+	backupKey := flag.String("backup-key", "backup.key", "Key signing unencrypted backups (created if missing, keep a copy)")
+	backupPassphrase := flag.String("backup-passphrase", os.Getenv("RTS_BACKUP_PASSPHRASE"), "Encrypt backups with this passphrase (default: $RTS_BACKUP_PASSPHRASE)")
+	backupDir := flag.String("backup-dir", "", "Directory of automatic backups in server mode (empty to disable)")
+	backupInterval := flag.Duration("backup-interval", 24*time.Hour, "Interval between automatic backups")
+	backupKeep := flag.Int("backup-keep", 7, "Number of automatic backups kept")
+	reserveBlock := flag.Int("rolling-code-block", config.DefaultReserveBlock, "Rolling codes reserved per config write (1 writes the config on every command)")
+	radioKind := flag.String("radio", "cc1101", "Radio backend: cc1101 or sim")
+	simLog := flag.String("sim-log", "", "File where the sim radio appends emitted frames (JSON lines)")
 
 	flag.Parse()
 
@@ -41,6 +108,9 @@ func main() {
 
 		// Initialiser le CC1101
 		fmt.Println("1. Initialisation de la connexion SPI...")
+		if _, err := host.Init(); err != nil {
+			log.Fatalf("❌ Échec de l'initialisation de periph.io: %v", err)
+		}
 		conn, err := radio.InitCC1101()
 		if err != nil {
 			log.Fatalf("❌ Échec de l'initialisation du CC1101: %v", err)
@@ -59,11 +129,104 @@ func main() {
 		return
 	}
 
+	// Mode distant : commandes envoyées via l'API d'un serveur
+	if *server != "" {
+		tlsConfig, err := client.TLSConfig(*serverCA, *clientCert, *clientKey)
+		if err != nil {
+			log.Fatalf("Invalid TLS options: %v", err)
+		}
+		if *exportPath != "" || *importPath != "" {
+			runRemoteBackup(*server, *token, tlsConfig, *exportPath, *importPath, *backupPassphrase, *force)
+			return
+		}
+		if runRemoteMode(*server, *token, tlsConfig, *listRemotes, *remoteName, *groupName, *command, *value, *repeats, *holdMs) {
+			return
+		}
+		log.Fatal("Usage: --server <url> (--list | --remote <name> --cmd <command> | --group <name> --cmd <command>)")
+	}
+
+	// Mode autorité de certification locale
+	if *initCA {
+		hosts := strings.Split(*tlsHosts, ",")
+		if *tlsHosts == "" {
+			hosts = []string{"localhost", "127.0.0.1", "::1"}
+			if hostname, err := os.Hostname(); err == nil {
+				hosts = append(hosts, hostname)
+			}
+		}
+		if err := pki.InitCA(*caDir, hosts); err != nil {
+			log.Fatalf("Failed to create certificates: %v", err)
+		}
+
+		fmt.Printf("Server certificate issued for: %s\n", strings.Join(hosts, ", "))
+		fmt.Printf("  --tls-cert %s --tls-key %s\n", filepath.Join(*caDir, pki.ServerFile), filepath.Join(*caDir, pki.ServerKeyFile))
+		fmt.Printf("Clients trust the CA with --server-ca %s\n", filepath.Join(*caDir, pki.CAFile))
+		return
+	}
+
+	if *issueClientCert != "" {
+		certPath, keyPath, err := pki.IssueClientCert(*caDir, *issueClientCert)
+		if err != nil {
+			log.Fatalf("Failed to issue client certificate: %v", err)
+		}
+
+		fmt.Printf("Client certificate '%s' issued\n", *issueClientCert)
+		fmt.Printf("  --client-cert %s --client-key %s\n", certPath, keyPath)
+		fmt.Printf("The server accepts it with --tls-client-ca %s if a token named '%s' exists\n", filepath.Join(*caDir, pki.CAFile), *issueClientCert)
+		return
+	}
+
 	// Charger la configuration
-	cfg, err := config.Load(*configPath)
+	// La base bbolt remplace remotes.json par remotes.db, qui reprend son contenu
+	if *storeKind == config.StoreBolt && filepath.Ext(*configPath) == ".json" {
+		*configPath = strings.TrimSuffix(*configPath, ".json") + ".db"
+	}
+
+	// Mode migration
+	if *migrate {
+		m, err := config.Migrate(*storeKind, *configPath, *dryRun)
+		if err != nil {
+			log.Fatalf("Failed to migrate config: %v", err)
+		}
+		if m == nil {
+			fmt.Printf("Config %s is already at schema version %d\n", *configPath, config.SchemaVersion)
+			return
+		}
+
+		fmt.Printf("Config %s: schema version %d → %d\n", *configPath, m.From, m.To)
+		for _, change := range m.Changes {
+			fmt.Printf("  %s\n", change)
+		}
+		if *dryRun {
+			fmt.Println("Dry run: nothing written")
+		} else {
+			fmt.Printf("Previous version kept in %s.v%d\n", *configPath, m.From)
+		}
+		return
+	}
+
+	cfg, err := config.Open(*storeKind, *configPath)
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
+	cfg.ReserveBlock = *reserveBlock
+	if *httpAddr == "" && *mqttBroker == "" {
+		// Commande unique : le code émis est sauvegardé avant l'émission, en
+		// une seule écriture, et la fermeture n'a plus rien à écrire
+		cfg.ReserveBlock = 1
+	}
+	defer cfg.Close()
+
+	// Mode sauvegarde
+	if *exportPath != "" || *importPath != "" {
+		keys := backupKeys(*backupKey, *backupPassphrase)
+		if *exportPath != "" {
+			exportBackup(cfg, *exportPath, keys)
+		} else {
+			importBackup(cfg, *importPath, keys, *force)
+		}
+		return
+	}
 
 	// Mode liste
 	if *listRemotes {
@@ -74,10 +237,180 @@ func main() {
 			fmt.Printf("Configured remotes (%d):\n", len(remotes))
 			for _, name := range remotes {
 				r := cfg.Remotes[name]
-				fmt.Printf("  - %s: address=0x%06X, rolling_code=%d\n",
+				fmt.Printf("  - %s: address=0x%06X, rolling_code=%d",
 					name, r.Address, r.RollingCode)
+				if r.Position != nil {
+					fmt.Printf(", position=%d%%", *r.Position)
+				}
+				fmt.Println()
 			}
 		}
+
+		groups := cfg.ListGroups()
+		if len(groups) > 0 {
+			fmt.Printf("Configured groups (%d):\n", len(groups))
+			for _, name := range groups {
+				g, _ := cfg.GetGroup(name)
+				fmt.Printf("  - %s: %s", name, strings.Join(g.Members, ", "))
+				if g.Remote != "" {
+					fmt.Printf(" (group remote: %s)", g.Remote)
+				}
+				fmt.Println()
+			}
+		}
+
+		schedules := cfg.ListSchedules()
+		if len(schedules) > 0 {
+			fmt.Printf("Configured schedules (%d):\n", len(schedules))
+			for _, name := range schedules {
+				sc, _ := cfg.GetSchedule(name)
+				fmt.Printf("  - %s: %s\n", name, describeSchedule(sc))
+				if next, ok := scheduler.NextRun(cfg, sc, time.Now()); ok {
+					fmt.Printf("      next run: %s\n", next.Format("Mon 2006-01-02 15:04 MST"))
+				}
+			}
+		}
+
+		tokens := cfg.ListTokens()
+		if len(tokens) > 0 {
+			fmt.Printf("API tokens (%d):\n", len(tokens))
+			for _, t := range tokens {
+				fmt.Printf("  - %s: %s (created %s)\n", t.Name, strings.Join(t.Scopes, ", "), t.CreatedAt.Format("2006-01-02"))
+				if len(t.Remotes) > 0 {
+					fmt.Printf("      remotes: %s\n", strings.Join(t.Remotes, ", "))
+				}
+			}
+		}
+
+		if lat, lon, ok := cfg.Coordinates(); ok {
+			fmt.Printf("Location: %.4f, %.4f", lat, lon)
+			if loc, err := time.LoadLocation(cfg.Timezone()); err == nil {
+				if rise, set, ok := solar.Times(time.Now().In(loc), lat, lon); ok {
+					fmt.Printf(" (today: sunrise %s, sunset %s)", rise.Format("15:04"), set.Format("15:04"))
+				}
+			}
+			fmt.Println()
+		}
+		return
+	}
+
+	// Mode gestion des jetons d'API
+	if *issueToken != "" {
+		scopeList, err := config.ParseScopes(*scopes)
+		if err != nil {
+			log.Fatalf("Invalid scopes: %v", err)
+		}
+
+		remoteList := config.ParseRemoteList(*tokenRemotes)
+		secret, err := cfg.IssueToken(*issueToken, scopeList, remoteList)
+		if err != nil {
+			log.Fatalf("Failed to issue token: %v", err)
+		}
+
+		fmt.Printf("Token '%s' issued with scopes: %s\n", *issueToken, strings.Join(scopeList, ", "))
+		if len(remoteList) > 0 {
+			fmt.Printf("Restricted to remotes: %s\n", strings.Join(remoteList, ", "))
+		}
+		fmt.Printf("  %s\n", secret)
+		fmt.Println("Store it now: it cannot be displayed again.")
+		return
+	}
+
+	if *revokeToken != "" {
+		if err := cfg.RevokeToken(*revokeToken); err != nil {
+			log.Fatalf("Failed to revoke token: %v", err)
+		}
+
+		fmt.Printf("Token '%s' revoked\n", *revokeToken)
+		return
+	}
+
+	// Mode gestion des programmations
+	if *timezone != "" {
+		if err := cfg.SetTimezone(*timezone); err != nil {
+			log.Fatalf("Failed to set timezone: %v", err)
+		}
+		fmt.Printf("Timezone set to %s\n", *timezone)
+		if *schedule == "" && math.IsNaN(*latitude) && math.IsNaN(*longitude) {
+			return
+		}
+	}
+
+	if !math.IsNaN(*latitude) || !math.IsNaN(*longitude) {
+		if math.IsNaN(*latitude) || math.IsNaN(*longitude) {
+			log.Fatal("Both --latitude and --longitude are required")
+		}
+		if err := cfg.SetCoordinates(*latitude, *longitude); err != nil {
+			log.Fatalf("Failed to set location: %v", err)
+		}
+		fmt.Printf("Location set to %.4f, %.4f\n", *latitude, *longitude)
+		if *schedule == "" {
+			return
+		}
+	}
+
+	if *schedule != "" {
+		sc, err := scheduler.Parse(*schedule)
+		if err != nil {
+			log.Fatalf("Invalid schedule: %v", err)
+		}
+		if *scheduleName != "" {
+			sc.Name = *scheduleName
+		}
+		if err := scheduler.CheckSite(cfg, sc); err != nil {
+			log.Fatalf("Invalid schedule: %v", err)
+		}
+
+		if err := cfg.AddSchedule(sc); err != nil {
+			log.Fatalf("Failed to add schedule: %v", err)
+		}
+
+		fmt.Printf("Schedule '%s' saved: %s\n", sc.Name, describeSchedule(sc))
+		return
+	}
+
+	if *deleteSchedule != "" {
+		if err := cfg.RemoveSchedule(*deleteSchedule); err != nil {
+			log.Fatalf("Failed to delete schedule: %v", err)
+		}
+
+		fmt.Printf("Schedule '%s' deleted\n", *deleteSchedule)
+		return
+	}
+
+	// Mode gestion des groupes
+	if *addGroup {
+		if *groupName == "" || *members == "" {
+			log.Fatal("Usage: --add-group --group <name> --members <remote1,remote2,...>")
+		}
+
+		memberList := strings.Split(*members, ",")
+		for i := range memberList {
+			memberList[i] = strings.TrimSpace(memberList[i])
+		}
+
+		group := config.Group{Name: *groupName, Members: memberList, Remote: *groupRemote}
+		if err := cfg.AddGroup(group); err != nil {
+			log.Fatalf("Failed to add group: %v", err)
+		}
+
+		fmt.Printf("Group '%s' saved: %s\n", *groupName, strings.Join(memberList, ", "))
+		if group.Remote != "" {
+			fmt.Printf("  Group remote: %s (pair it with --pair-group --group %s)\n", group.Remote, *groupName)
+		}
+		return
+	}
+
+	if *deleteGroup {
+		if *groupName == "" {
+			log.Fatal("Usage: --delete-group --group <name>")
+		}
+
+		if err := cfg.RemoveGroup(*groupName); err != nil {
+			log.Fatalf("Failed to delete group: %v", err)
+		}
+
+		fmt.Printf("Group '%s' deleted\n", *groupName)
 		return
 	}
 
@@ -92,6 +425,8 @@ func main() {
 			Address:       uint32(*address),
 			RollingCode:   uint16(*rollingCode),
 			EncryptionKey: byte(*encKey),
+			TravelUpMs:    *travelUp,
+			TravelDownMs:  *travelDown,
 		}
 
 		if err := cfg.AddRemote(*remoteName, rc); err != nil {
@@ -102,42 +437,150 @@ func main() {
 		fmt.Printf("  Address: 0x%06X\n", rc.Address)
 		fmt.Printf("  Rolling Code: %d\n", rc.RollingCode)
 		fmt.Printf("  Encryption Key: 0x%02X\n", rc.EncryptionKey)
+		if rc.TracksPosition() {
+			fmt.Printf("  Travel time: up %dms, down %dms\n", rc.TravelUpMs, rc.TravelDownMs)
+		}
 		return
 	}
 
-	// Initialiser le CC1101
-	conn, err := radio.InitCC1101()
+	// Initialiser l'émetteur radio
+	tx, closeRadio, err := openTransmitter(*radioKind, *simLog)
 	if err != nil {
-		log.Fatalf("Failed to initialize CC1101: %v", err)
+		log.Fatalf("Failed to initialize radio: %v", err)
 	}
-	// Note: spi.Conn n'a pas de méthode Close, la connexion est gérée par periph.io
+	defer closeRadio()
 
 	// Créer le contrôleur
-	ctrl := controller.New(cfg, conn)
+	ctrl := controller.New(cfg, tx)
+	if *auditPath != "" {
+		opts := audit.Options{MaxSize: int64(*auditMaxMB) << 20, MaxFiles: *auditFiles, MaxEntries: *auditMaxEntries}
+		var auditLog audit.History
+		if *storeKind == config.StoreBolt {
+			// Historique dans la base, qui reprend le fichier JSON lines existant
+			auditLog, err = cfg.History(*auditPath, opts)
+		} else {
+			auditLog, err = audit.Open(*auditPath, opts)
+		}
+		if err != nil {
+			log.Fatalf("Failed to open audit log: %v", err)
+		}
+		defer auditLog.Close()
+		ctrl.SetAuditLog(auditLog)
+	}
+
+	// Mode serveur HTTP et/ou MQTT (avec le planificateur)
+	if *httpAddr != "" || *mqttBroker != "" {
+		sched := scheduler.New(ctrl)
+		sched.Start()
+
+		// Positions sauvegardées régulièrement, rolling codes à l'arrêt
+		cfg.AutoFlush(time.Minute)
+
+		// Clé de sauvegarde lue (ou créée) seulement si elle sert : sauvegardes
+		// locales ici, sinon à la première requête de sauvegarde de l'API
+		if *backupDir != "" {
+			local := &backup.Local{Dir: *backupDir, Keep: *backupKeep, Keys: backupKeys(*backupKey, *backupPassphrase)}
+			local.Start(*backupInterval, cfg.Export)
+		}
+
+		// Arrêts à effectuer avant la sauvegarde finale de la configuration
+		var stop []func() error
+		if *mqttBroker != "" {
+			bridge := mqtt.New(ctrl, mqtt.Options{
+				Broker:          *mqttBroker,
+				Username:        *mqttUser,
+				Password:        *mqttPassword,
+				Topic:           *mqttTopic,
+				DiscoveryPrefix: *mqttDiscovery,
+				Remotes:         config.ParseRemoteList(*mqttRemotes),
+			})
+			if err := bridge.Start(); err != nil {
+				log.Fatalf("Failed to start MQTT: %v", err)
+			}
+			stop = append(stop, func() error {
+				bridge.Stop()
+				return nil
+			})
+		}
+		go closeOnSignal(cfg, append(stop, closeRadio)...)
+
+		if *httpAddr != "" {
+			server := api.NewServer(ctrl)
+			server.SetBackupKeyFile(*backupKey)
+			if *tlsCert != "" || *tlsKey != "" {
+				log.Fatal(server.StartTLS(*httpAddr, api.TLSOptions{
+					CertFile:          *tlsCert,
+					KeyFile:           *tlsKey,
+					ClientCAFile:      *tlsClientCA,
+					RequireClientCert: *tlsRequireClient,
+				}))
+			}
+			log.Fatal(server.Start(*httpAddr))
+		}
+		select {}
+	}
+
+	// Mode appairage d'une télécommande de groupe
+	if *pairGroup {
+		if *groupName == "" {
+			log.Fatal("Usage: --pair-group --group <name> [--remote <member>]")
+		}
+
+		results, err := ctrl.PairGroupRemote(*groupName, *remoteName)
+		if err != nil {
+			log.Fatalf("Failed to pair group remote: %v", err)
+		}
+		printMemberResults(results)
+		if !controller.AllSucceeded(results) {
+			log.Fatalf("Pairing failed for some members of group '%s'", *groupName)
+		}
+
+		fmt.Printf("Group remote of '%s' paired successfully!\n", *groupName)
+		return
+	}
+
+	// Mode CLI - envoi à un groupe
+	if *groupName != "" && *command != "" {
+		var results []controller.MemberResult
+		if *command == controller.PositionCommand {
+			results, err = ctrl.SetGroupPosition(*groupName, *value)
+		} else {
+			cmd, ok := remote.ParseCommand(*command)
+			if !ok {
+				log.Fatalf("Unknown command: %s (use: %s)", *command, strings.Join(remote.CommandNames(), ", "))
+			}
+			results, err = ctrl.SendGroup(*groupName, cmd.Code, cliSendOptions(cmd, *repeats, *holdMs))
+		}
+		if err != nil {
+			log.Fatalf("Failed to send command: %v", err)
+		}
+
+		printMemberResults(results)
+		if !controller.AllSucceeded(results) {
+			log.Fatalf("Command '%s' failed for some members of group '%s'", *command, *groupName)
+		}
 
-	// Mode serveur HTTP
-	if *httpAddr != "" {
-		server := api.NewServer(ctrl)
-		log.Fatal(server.Start(*httpAddr))
+		fmt.Printf("Command '%s' sent to group '%s' successfully!\n", *command, *groupName)
+		return
 	}
 
 	// Mode CLI - envoi de commande unique
+	if *remoteName != "" && *command == controller.PositionCommand {
+		if err := ctrl.SetPosition(*remoteName, *value); err != nil {
+			log.Fatalf("Failed to set position: %v", err)
+		}
+
+		fmt.Printf("Remote '%s' moved to %d%%\n", *remoteName, *value)
+		return
+	}
+
 	if *remoteName != "" && *command != "" {
-		var cmdByte byte
-		switch *command {
-		case "up", "monter":
-			cmdByte = remote.CmdUp
-		case "down", "descendre":
-			cmdByte = remote.CmdDown
-		case "my", "stop":
-			cmdByte = remote.CmdMy
-		case "prog", "program":
-			cmdByte = remote.CmdProg
-		default:
-			log.Fatalf("Unknown command: %s (use: up, down, my, stop, prog)", *command)
-		}
-
-		if err := ctrl.SendCommand(*remoteName, cmdByte); err != nil {
+		cmd, ok := remote.ParseCommand(*command)
+		if !ok {
+			log.Fatalf("Unknown command: %s (use: %s)", *command, strings.Join(remote.CommandNames(), ", "))
+		}
+
+		if err := ctrl.SendCommandWithOptions(*remoteName, cmd.Code, cliSendOptions(cmd, *repeats, *holdMs)); err != nil {
 			log.Fatalf("Failed to send command: %v", err)
 		}
 
@@ -148,10 +591,34 @@ func main() {
 	// Aucune action spécifiée
 	fmt.Println("Usage:")
 	fmt.Println("  Test CC1101:     --test")
-	fmt.Println("  List remotes:    --list")
+	fmt.Println("  List remotes:    --list (remotes and groups)")
 	fmt.Println("  Add remote:      --add --remote <name> --address <addr>")
-	fmt.Println("  Send command:    --remote <name> --cmd <up|down|my|prog>")
-	fmt.Println("  Start HTTP API:  --http :8080")
+	fmt.Println("  Send command:    --remote <name> --cmd <command>")
+	fmt.Println("                   commands: " + strings.Join(remote.CommandNames(), ", "))
+	fmt.Println("  Long press:      --remote <name> --cmd <command> --hold-ms <ms>")
+	fmt.Println("  Set position:    --remote <name> --cmd position --value <0-100>")
+	fmt.Println("  Add group:       --add-group --group <name> --members <remote1,remote2>")
+	fmt.Println("  Delete group:    --delete-group --group <name>")
+	fmt.Println("  Group command:   --group <name> --cmd <command>")
+	fmt.Println("  Group remote:    --add-group --group <name> --members <...> --group-remote <remote>")
+	fmt.Println("  Pair group:      --pair-group --group <name> [--remote <member>]")
+	fmt.Println("  Add schedule:    --schedule \"<days> <HH:MM|sunrise|sunset[+-offset]> <command> [value] <remote|group name>\" [--schedule-name <name>]")
+	fmt.Println("  Delete schedule: --delete-schedule <name>")
+	fmt.Println("  Set timezone:    --timezone Europe/Paris")
+	fmt.Println("  Set location:    --latitude 48.8566 --longitude 2.3522 (for sunrise/sunset schedules)")
+	fmt.Println("  Start HTTP API:  --http :8080 (also runs the schedules)")
+	fmt.Println("  HTTPS:           --http :8443 --tls-cert <cert.pem> --tls-key <key.pem> [--tls-client-ca <ca.pem> [--tls-require-client-cert]]")
+	fmt.Println("  Local CA:        --init-ca [--ca-dir pki] [--tls-hosts host1,ip1] | --issue-client-cert <name> [--ca-dir pki]")
+	fmt.Println("  Start MQTT:      --mqtt tcp://broker:1883 [--mqtt-user u --mqtt-password p] (Home Assistant discovery)")
+	fmt.Println("  Without CC1101:  --radio sim [--sim-log frames.jsonl]")
+	fmt.Println("  Remote mode:     --server http://raspberrypi:8080 [--token <token>] (--list or --cmd through a running server)")
+	fmt.Println("                   https: [--server-ca pki/ca.pem] [--client-cert <cert.pem> --client-key <key.pem>]")
+	fmt.Println("  Issue token:     --issue-token <name> --scopes " + strings.Join(config.Scopes, ",") + " [--token-remotes r1,r2]")
+	fmt.Println("  Revoke token:    --revoke-token <name>")
+	fmt.Println("  Config store:    --store bolt (embedded database remotes.db, imports remotes.json)")
+	fmt.Println("  Migrate config:  --migrate [--dry-run] (to the current schema version)")
+	fmt.Println("  Backup:          --export <file.rtsbak> | --import <file.rtsbak> [--force] [--backup-passphrase <secret>]")
+	fmt.Println("  Auto backups:    --backup-dir backups [--backup-interval 24h] [--backup-keep 7] (server mode)")
 	fmt.Println("")
 	fmt.Println("Examples:")
 	fmt.Println("  Test CC1101 module:")
@@ -162,4 +629,111 @@ func main() {
 	fmt.Println("    ./rtsCommander --remote salon --cmd up")
 	fmt.Println("  Start HTTP server:")
 	fmt.Println("    ./rtsCommander --http :8080")
+	fmt.Println("  Start HTTP server with the simulated radio:")
+	fmt.Println("    ./rtsCommander --http :8080 --radio sim")
+}
+
+// closeOnSignal sauvegarde la configuration à l'arrêt du serveur (Ctrl+C,
+// docker stop) : les rolling codes réservés mais non émis ne seront pas sautés.
+// Les fonctions stop sont appelées avant, dans l'ordre.
+func closeOnSignal(cfg *config.Config, stop ...func() error) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+
+	for _, fn := range stop {
+		if err := fn(); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+	if err := cfg.Close(); err != nil {
+		log.Printf("Warning: failed to save config: %v", err)
+	}
+	stats := cfg.Stats()
+	log.Printf("Stopped: %d config write(s) for %d rolling code(s), %d write(s) saved", stats.Writes, stats.RollingCodes, stats.WritesSaved)
+	os.Exit(0)
+}
+
+// describeSchedule décrit une programmation sur une ligne
+func describeSchedule(sc config.Schedule) string {
+	days := "every day"
+	if len(sc.Days) > 0 {
+		days = strings.Join(sc.Days, ",")
+	}
+	target := sc.Remote
+	if sc.Group != "" {
+		target = "group " + sc.Group
+	}
+	command := sc.Command
+	if sc.Value != nil {
+		command = fmt.Sprintf("%s %d", sc.Command, *sc.Value)
+	}
+	desc := fmt.Sprintf("%s %s %s %s", days, sc.Time, command, target)
+	if sc.Timezone != "" {
+		desc += " (" + sc.Timezone + ")"
+	}
+	if sc.Disabled {
+		desc += " [disabled]"
+	}
+	return desc
+}
+
+// printMemberResults affiche le résultat d'une commande pour chaque membre d'un groupe
+func printMemberResults(results []controller.MemberResult) {
+	for _, r := range results {
+		if r.Success {
+			fmt.Printf("  ✓ %s\n", r.Remote)
+		} else {
+			fmt.Printf("  ✗ %s: %s\n", r.Remote, r.Error)
+		}
+	}
+}
+
+// cliSendOptions retourne les options d'envoi demandées en ligne de commande
+func cliSendOptions(cmd remote.Command, repeats, holdMs int) controller.SendOptions {
+	opts := controller.SendOptions{Repeats: cmd.Repeats}
+	if repeats > 0 {
+		opts.Repeats = repeats
+	}
+	if holdMs > 0 {
+		opts.Hold = time.Duration(holdMs) * time.Millisecond
+	}
+	return opts
+}
+
+// openTransmitter initialise le backend radio choisi. La fonction retournée
+// libère ses ressources (journal du simulateur, synchronisé sur le disque).
+func openTransmitter(kind, simLog string) (radio.Transmitter, func() error, error) {
+	noop := func() error { return nil }
+	switch kind {
+	case "cc1101":
+		// Initialiser periph.io pour accéder au hardware
+		if _, err := host.Init(); err != nil {
+			return nil, nil, fmt.Errorf("failed to initialize periph.io: %v", err)
+		}
+		conn, err := radio.InitCC1101()
+		if err != nil {
+			return nil, nil, err
+		}
+		// Note: spi.Conn n'a pas de méthode Close, la connexion est gérée par periph.io
+		return radio.NewCC1101(conn), noop, nil
+	case "sim":
+		if simLog == "" {
+			return radio.NewSimulator(radio.DefaultSimBufferSize, nil), noop, nil
+		}
+		f, err := os.OpenFile(simLog, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open sim log: %v", err)
+		}
+		closeLog := func() error {
+			if err := f.Sync(); err != nil {
+				f.Close()
+				return err
+			}
+			return f.Close()
+		}
+		return radio.NewSimulator(radio.DefaultSimBufferSize, f), closeLog, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown radio backend: %s (use: cc1101, sim)", kind)
+	}
 }
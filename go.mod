@@ -4,4 +4,15 @@ go 1.24.3
 
 require periph.io/x/conn/v3 v3.7.2
 
-require periph.io/x/host/v3 v3.8.5 // indirect
+require (
+	github.com/eclipse/paho.mqtt.golang v1.5.0
+	go.etcd.io/bbolt v1.3.11
+	periph.io/x/host/v3 v3.8.5
+)
+
+require (
+	github.com/gorilla/websocket v1.5.3 // indirect
+	golang.org/x/net v0.27.0 // indirect
+	golang.org/x/sync v0.7.0 // indirect
+	golang.org/x/sys v0.22.0 // indirect
+)
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"rtscommander/m/internal/config"
+	"rtscommander/m/internal/controller"
+)
+
+// Routes accessibles sans jeton
+var publicPaths = map[string]bool{
+	"/openapi.json": true,
+}
+
+// Flux d'événements : un navigateur (EventSource) ne peut pas envoyer d'en-tête
+// Authorization, le jeton est alors accepté dans le paramètre access_token
+var streamPaths = map[string]bool{
+	"/events":         true,
+	apiV1 + "/events": true,
+}
+
+// requiredScope retourne la portée nécessaire à une requête : command pour
+// l'envoi de commandes, read pour les lectures, admin pour les modifications
+// et les sauvegardes.
+// path est le chemin encodé : un nom contenant "/" ne peut pas imiter une route.
+func requiredScope(method, path string) string {
+	switch {
+	case path == apiV1+"/backup":
+		return config.ScopeAdmin // L'archive contient les rolling codes
+	case method == http.MethodPost && isCommandPath(path):
+		return config.ScopeCommand
+	case method == http.MethodGet || method == http.MethodHead:
+		return config.ScopeRead
+	}
+	return config.ScopeAdmin
+}
+
+// isCommandPath indique si un chemin désigne l'envoi d'une commande :
+// /command ou /api/v1/{remotes|groups}/{name}/commands/{cmd}
+func isCommandPath(path string) bool {
+	if path == "/command" {
+		return true
+	}
+	parts := strings.Split(strings.TrimPrefix(path, apiV1+"/"), "/")
+	return len(parts) == 4 && (parts[0] == "remotes" || parts[0] == "groups") && parts[2] == "commands" &&
+		strings.HasPrefix(path, apiV1+"/")
+}
+
+// bearerToken extrait le jeton d'une requête
+func bearerToken(r *http.Request) string {
+	if scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	if streamPaths[r.URL.Path] {
+		return r.URL.Query().Get("access_token")
+	}
+	return ""
+}
+
+// clientCertName retourne le nom commun du certificat client vérifié de la
+// requête (TLS mutuel), vide s'il n'y en a pas
+func clientCertName(r *http.Request) string {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return r.TLS.VerifiedChains[0][0].Subject.CommonName
+}
+
+// authenticate exige un jeton portant la portée requise par chaque route. Un
+// certificat client vérifié tient lieu du jeton de même nom. Tant qu'aucun
+// jeton n'est configuré, l'API reste ouverte.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg := s.ctrl.Config()
+		if publicPaths[r.URL.Path] || !cfg.HasTokens() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var token config.Token
+		secret, certName := bearerToken(r), clientCertName(r)
+		switch {
+		case secret != "":
+			var ok bool
+			if token, ok = cfg.Authenticate(secret); !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="rtscommander", error="invalid_token"`)
+				sendJSONError(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+		case certName != "":
+			var ok bool
+			if token, ok = cfg.GetToken(certName); !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="rtscommander"`)
+				sendJSONError(w, "Client certificate '"+certName+"' matches no token", http.StatusUnauthorized)
+				return
+			}
+		default:
+			w.Header().Set("WWW-Authenticate", `Bearer realm="rtscommander"`)
+			sendJSONError(w, "Missing bearer token", http.StatusUnauthorized)
+			return
+		}
+
+		scope := requiredScope(r.Method, r.URL.EscapedPath())
+		if !token.Allows(scope) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="rtscommander", error="insufficient_scope", scope="`+scope+`"`)
+			sendJSONError(w, "Token '"+token.Name+"' lacks the '"+scope+"' scope", http.StatusForbidden)
+			return
+		}
+
+		c := &caller{
+			principal: &controller.Principal{Source: controller.SourceHTTP, Name: "token:" + token.Name, Remotes: token.Remotes},
+			admin:     token.Allows(config.ScopeAdmin),
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), callerKey{}, c)))
+	})
+}
+
+// caller représente le jeton authentifié d'une requête
+type caller struct {
+	principal *controller.Principal
+	admin     bool // Portée admin : accès aux secrets d'appairage des télécommandes
+}
+
+type callerKey struct{}
+
+// callerOf retourne le jeton d'une requête ; nil si l'API est ouverte
+func callerOf(r *http.Request) *caller {
+	c, _ := r.Context().Value(callerKey{}).(*caller)
+	return c
+}
+
+// canSee indique si la requête peut consulter une télécommande : celles hors
+// de la liste d'accès du jeton sont invisibles
+func canSee(r *http.Request, remoteName string) bool {
+	c := callerOf(r)
+	return c == nil || c.principal.Allows(remoteName)
+}
+
+// visibleState retourne l'état d'une télécommande tel que la requête peut le
+// voir. Sans portée admin, adresse, rolling code et clé sont masqués : ils
+// suffisent à imiter la télécommande.
+func visibleState(r *http.Request, state *controller.RemoteState) *controller.RemoteState {
+	if c := callerOf(r); c != nil && !c.admin {
+		return state.Redact()
+	}
+	return state
+}
+
+// session retourne la session du contrôleur au nom du jeton de la requête, sans
+// restriction si l'API est ouverte. L'adresse du client est inscrite à l'audit.
+func (s *Server) session(r *http.Request) *controller.Session {
+	principal := controller.Principal{Source: controller.SourceHTTP}
+	if c := callerOf(r); c != nil {
+		principal = *c.principal
+	}
+	principal.Address = r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		principal.Address = host
+	}
+	return s.ctrl.As(&principal)
+}
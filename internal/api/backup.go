@@ -0,0 +1,103 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"rtscommander/m/internal/backup"
+	"rtscommander/m/internal/config"
+)
+
+// En-tête portant la phrase secrète d'une sauvegarde chiffrée
+const passphraseHeader = "X-Backup-Passphrase"
+
+// Taille maximale d'une sauvegarde envoyée à /api/v1/backup
+const maxBackupSize = 10 << 20
+
+// backupFilters décrit les paramètres de /api/v1/backup (document OpenAPI)
+var backupFilters = map[string]string{
+	"force": "Restore even if a rolling code would go backwards (true)",
+}
+
+// SetBackupKeyFile définit le fichier de la clé de signature des sauvegardes
+// en clair. Il n'est lu, ou créé, qu'à la première sauvegarde ou restauration.
+func (s *Server) SetBackupKeyFile(path string) {
+	s.backupMu.Lock()
+	defer s.backupMu.Unlock()
+
+	s.backupKeyFile = path
+	s.backupKey = nil
+}
+
+// backupKeys retourne les secrets d'une requête de sauvegarde
+func (s *Server) backupKeys(r *http.Request) (backup.Keys, error) {
+	s.backupMu.Lock()
+	defer s.backupMu.Unlock()
+
+	if s.backupKey == nil && s.backupKeyFile != "" {
+		key, err := backup.LoadKey(s.backupKeyFile)
+		if err != nil {
+			return backup.Keys{}, fmt.Errorf("failed to load backup key: %v", err)
+		}
+		s.backupKey = key
+	}
+	return backup.Keys{SigningKey: s.backupKey, Passphrase: r.Header.Get(passphraseHeader)}, nil
+}
+
+// handleBackup exporte la configuration dans une archive signée, chiffrée si
+// la requête porte une phrase secrète
+func (s *Server) handleBackup(w http.ResponseWriter, r *http.Request) {
+	payload, err := s.ctrl.Config().Export()
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	keys, err := s.backupKeys(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data, err := backup.Seal(payload, keys)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, backup.FileName(time.Now())))
+	w.Write(data)
+}
+
+// handleRestore restaure une archive ; un rolling code qui reculerait la fait
+// refuser, sauf avec ?force=true
+func (s *Server) handleRestore(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBackupSize))
+	if err != nil {
+		sendJSONError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	keys, err := s.backupKeys(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	payload, err := backup.Open(data, keys)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := s.ctrl.Restore(payload, r.URL.Query().Get("force") == "true")
+	if errors.Is(err, config.ErrRollingCodeRegression) {
+		sendJSONError(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendJSONResponse(w, report)
+}
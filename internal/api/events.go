@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Intervalle des commentaires envoyés pour garder le flux ouvert à travers
+// les proxies
+const eventKeepAlive = 30 * time.Second
+
+// handleEvents diffuse les événements du contrôleur en Server-Sent Events.
+// Le paramètre optionnel remote restreint le flux à une télécommande.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		sendJSONError(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	only := r.URL.Query().Get("remote")
+
+	events, unsubscribe := s.ctrl.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case ev := <-events:
+			if only != "" && ev.Remote != only {
+				continue
+			}
+			if ev.Remote != "" && !canSee(r, ev.Remote) {
+				continue
+			}
+			if c := callerOf(r); c != nil && !c.admin {
+				ev.RollingCode = nil // Secret d'appairage, comme dans l'état des télécommandes
+			}
+			data, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"rtscommander/m/internal/config"
+	"rtscommander/m/internal/controller"
+	"rtscommander/m/internal/remote"
+)
+
+// handleGroupCommand envoie une commande à tous les membres d'un groupe
+func (s *Server) handleGroupCommand(w http.ResponseWriter, r *http.Request, req *CommandRequest) {
+	if req.Remote != "" {
+		sendJSONError(w, "Use either 'remote' or 'group', not both", http.StatusBadRequest)
+		return
+	}
+
+	var results []controller.MemberResult
+	var err error
+	var message string
+
+	if strings.EqualFold(req.Command, controller.PositionCommand) {
+		if req.Value == nil {
+			sendJSONError(w, "Missing 'value' for position command", http.StatusBadRequest)
+			return
+		}
+		results, err = s.session(r).StartGroupPosition(req.Group, *req.Value)
+		message = fmt.Sprintf("Moving group '%s' to %d%%", req.Group, *req.Value)
+	} else {
+		cmd, ok := remote.ParseCommand(req.Command)
+		if !ok {
+			sendJSONError(w, fmt.Sprintf("Unknown command: %s", req.Command), http.StatusBadRequest)
+			return
+		}
+		opts, optErr := req.options(cmd)
+		if optErr != nil {
+			sendJSONError(w, optErr.Error(), http.StatusBadRequest)
+			return
+		}
+		results, err = s.session(r).SendGroup(req.Group, cmd.Code, opts)
+		message = fmt.Sprintf("Command '%s' sent to group '%s'", req.Command, req.Group)
+	}
+
+	if err != nil {
+		sendJSONError(w, err.Error(), errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	resp := CommandResponse{
+		Success: controller.AllSucceeded(results),
+		Message: message,
+		Group:   req.Group,
+		Results: results,
+	}
+	if !resp.Success {
+		resp.Message = fmt.Sprintf("Command '%s' failed for some members of group '%s'", req.Command, req.Group)
+		status := http.StatusInternalServerError
+		if controller.AllDenied(results) {
+			status = http.StatusForbidden
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+	sendJSONResponse(w, resp)
+}
+
+// GroupNameList représente la liste des noms de groupes (ancien endpoint /groups)
+type GroupNameList struct {
+	Groups []string `json:"groups"`
+	Count  int      `json:"count"`
+}
+
+// handleListGroups liste tous les groupes
+func (s *Server) handleListGroups(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	groups := s.ctrl.Config().ListGroups()
+	sendJSONResponse(w, GroupNameList{Groups: groups, Count: len(groups)})
+}
+
+// handleGroup obtient (GET) ou supprime (DELETE) un groupe
+func (s *Server) handleGroup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		sendJSONError(w, "Missing 'name' parameter", http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		if err := s.ctrl.Config().RemoveGroup(name); err != nil {
+			sendJSONError(w, err.Error(), errorStatus(err, http.StatusInternalServerError))
+			return
+		}
+		sendJSONResponse(w, CommandResponse{
+			Success: true,
+			Message: fmt.Sprintf("Group '%s' deleted", name),
+			Group:   name,
+		})
+		return
+	}
+
+	group, exists := s.ctrl.Config().GetGroup(name)
+	if !exists {
+		sendJSONError(w, fmt.Sprintf("Group '%s' not found", name), http.StatusNotFound)
+		return
+	}
+	sendJSONResponse(w, group)
+}
+
+// handleAddGroup ajoute ou remplace un groupe
+func (s *Server) handleAddGroup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var group config.Group
+	if err := json.NewDecoder(r.Body).Decode(&group); err != nil {
+		sendJSONError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if group.Name == "" {
+		sendJSONError(w, "Missing group name", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.ctrl.Config().AddGroup(group); err != nil {
+		sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sendJSONResponse(w, CommandResponse{
+		Success: true,
+		Message: fmt.Sprintf("Group '%s' saved with %d member(s)", group.Name, len(group.Members)),
+		Group:   group.Name,
+	})
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"rtscommander/m/internal/audit"
+)
+
+// Nombre d'entrées retournées par défaut et au maximum par /api/v1/history
+const (
+	historyDefaultLimit = 100
+	historyMaxLimit     = 1000
+)
+
+// History représente des entrées du journal d'audit, de la plus ancienne à la
+// plus récente
+type History struct {
+	Entries []audit.Entry `json:"entries"`
+}
+
+// historyFilters décrit les paramètres de /api/v1/history (document OpenAPI)
+var historyFilters = map[string]string{
+	"remote":    "Only commands sent to this remote",
+	"source":    "Only commands from this source: cli, http, mqtt or schedule",
+	"principal": "Only commands of this caller, e.g. token:tablette",
+	"since":     "Start time, RFC 3339 (2026-10-17T03:00:00+02:00) or duration before now (24h)",
+	"until":     "End time (excluded), same formats as since",
+	"limit":     fmt.Sprintf("Maximum number of entries, the most recent ones (default %d, max %d)", historyDefaultLimit, historyMaxLimit),
+}
+
+// handleHistory interroge le journal d'audit des commandes
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	auditLog := s.ctrl.AuditLog()
+	if auditLog == nil {
+		sendJSONError(w, "Audit log is disabled (see --audit-log)", http.StatusServiceUnavailable)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := audit.Filter{
+		Remote:    query.Get("remote"),
+		Source:    query.Get("source"),
+		Principal: query.Get("principal"),
+		Limit:     historyDefaultLimit,
+		Visible:   func(remote string) bool { return canSee(r, remote) },
+	}
+
+	var err error
+	if filter.Since, err = parseHistoryTime(query.Get("since")); err != nil {
+		sendJSONError(w, fmt.Sprintf("Invalid 'since': %v", err), http.StatusBadRequest)
+		return
+	}
+	if filter.Until, err = parseHistoryTime(query.Get("until")); err != nil {
+		sendJSONError(w, fmt.Sprintf("Invalid 'until': %v", err), http.StatusBadRequest)
+		return
+	}
+	if limit := query.Get("limit"); limit != "" {
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit < 1 || filter.Limit > historyMaxLimit {
+			sendJSONError(w, fmt.Sprintf("Invalid 'limit' (expected 1-%d)", historyMaxLimit), http.StatusBadRequest)
+			return
+		}
+	}
+
+	entries, err := auditLog.Query(filter)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if c := callerOf(r); c != nil && !c.admin {
+		for i := range entries {
+			entries[i].RollingCode = nil // Secret d'appairage, comme dans l'état des télécommandes
+		}
+	}
+	sendJSONResponse(w, History{Entries: entries})
+}
+
+// parseHistoryTime lit une date RFC 3339 ou une durée écoulée depuis maintenant
+func parseHistoryTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("expected an RFC 3339 time or a duration such as 24h")
+	}
+	return time.Now().Add(-d), nil
+}
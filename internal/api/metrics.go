@@ -0,0 +1,17 @@
+package api
+
+import (
+	"net/http"
+
+	"rtscommander/m/internal/config"
+)
+
+// Metrics représente les compteurs de fonctionnement du serveur
+type Metrics struct {
+	Storage config.StorageStats `json:"storage"` // Écritures de la configuration
+}
+
+// handleMetrics retourne les compteurs depuis le démarrage
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	sendJSONResponse(w, Metrics{Storage: s.ctrl.Config().Stats()})
+}
@@ -0,0 +1,365 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"rtscommander/m/internal/audit"
+	"rtscommander/m/internal/backup"
+	"rtscommander/m/internal/config"
+	"rtscommander/m/internal/controller"
+	"rtscommander/m/internal/remote"
+)
+
+// Version du document OpenAPI, à incrémenter avec l'API
+const openAPIVersion = "1.0.0"
+
+// Noms des schémas dont le type Go porte un nom trop générique
+var schemaNames = map[reflect.Type]string{
+	reflect.TypeOf(remote.Control{}): "Remote",
+	reflect.TypeOf(audit.Entry{}):    "AuditEntry",
+	reflect.TypeOf(backup.Archive{}): "BackupArchive",
+}
+
+// operation décrit un endpoint du document OpenAPI
+type operation struct {
+	method   string
+	path     string
+	summary  string
+	tag      string
+	query    []string          // Paramètres de requête requis
+	filters  map[string]string // Paramètres de requête facultatifs et leur description
+	body     interface{}       // Type du corps JSON (nil = aucun)
+	optional bool              // Corps facultatif
+	status   int               // Statut de la réponse en cas de succès
+	response interface{}       // Type de la réponse (nil = aucun contenu)
+	errors   []int             // Statuts d'erreur possibles
+	stream   bool              // Réponse text/event-stream
+}
+
+// operations liste les endpoints de l'API ; elle doit suivre routes et routesV1
+var operations = []operation{
+	{method: "GET", path: "/api/v1/remotes", summary: "List remotes with their estimated state", tag: "remotes", response: RemoteList{}},
+	{method: "POST", path: "/api/v1/remotes", summary: "Create a remote", tag: "remotes", body: remote.Control{}, status: http.StatusCreated, response: controller.RemoteState{}, errors: []int{400, 409}},
+	{method: "GET", path: "/api/v1/remotes/{name}", summary: "Get a remote and its estimated position", tag: "remotes", response: controller.RemoteState{}, errors: []int{404}},
+	{method: "PUT", path: "/api/v1/remotes/{name}", summary: "Create or replace a remote (omitted address, rolling_code and encryption_key are kept)", tag: "remotes", body: remote.Control{}, response: controller.RemoteState{}, errors: []int{400}},
+	{method: "PATCH", path: "/api/v1/remotes/{name}", summary: "Update some fields of a remote, or rename it", tag: "remotes", body: RemotePatch{}, response: controller.RemoteState{}, errors: []int{400, 404, 409}},
+	{method: "DELETE", path: "/api/v1/remotes/{name}", summary: "Delete a remote", tag: "remotes", status: http.StatusNoContent, errors: []int{404, 409}},
+	{method: "POST", path: "/api/v1/remotes/{name}/commands/{cmd}", summary: "Send a command to a remote", tag: "commands", body: CommandOptions{}, optional: true, response: CommandResponse{}, errors: []int{400, 404, 500}},
+
+	{method: "GET", path: "/api/v1/groups", summary: "List groups", tag: "groups", response: GroupList{}},
+	{method: "POST", path: "/api/v1/groups", summary: "Create a group", tag: "groups", body: config.Group{}, status: http.StatusCreated, response: config.Group{}, errors: []int{400, 409}},
+	{method: "GET", path: "/api/v1/groups/{name}", summary: "Get a group", tag: "groups", response: config.Group{}, errors: []int{404}},
+	{method: "PUT", path: "/api/v1/groups/{name}", summary: "Create or replace a group", tag: "groups", body: config.Group{}, response: config.Group{}, errors: []int{400}},
+	{method: "DELETE", path: "/api/v1/groups/{name}", summary: "Delete a group", tag: "groups", status: http.StatusNoContent, errors: []int{404}},
+	{method: "POST", path: "/api/v1/groups/{name}/commands/{cmd}", summary: "Send a command to every member of a group", tag: "commands", body: CommandOptions{}, optional: true, response: CommandResponse{}, errors: []int{400, 404, 500}},
+
+	{method: "GET", path: "/api/v1/schedules", summary: "List schedules", tag: "schedules", response: ScheduleList{}},
+	{method: "POST", path: "/api/v1/schedules", summary: "Create a schedule", tag: "schedules", body: ScheduleRequest{}, status: http.StatusCreated, response: ScheduleInfo{}, errors: []int{400, 409}},
+	{method: "GET", path: "/api/v1/schedules/{name}", summary: "Get a schedule and its next run", tag: "schedules", response: ScheduleInfo{}, errors: []int{404}},
+	{method: "PUT", path: "/api/v1/schedules/{name}", summary: "Create or replace a schedule", tag: "schedules", body: ScheduleRequest{}, response: ScheduleInfo{}, errors: []int{400}},
+	{method: "DELETE", path: "/api/v1/schedules/{name}", summary: "Delete a schedule", tag: "schedules", status: http.StatusNoContent, errors: []int{404}},
+
+	{method: "GET", path: "/api/v1/events", summary: "Stream controller events (Server-Sent Events, one Event per data line)", tag: "events", response: controller.Event{}, stream: true},
+	{method: "GET", path: "/api/v1/history", summary: "Query the audit log of transmitted and denied commands", tag: "history", filters: historyFilters, response: History{}, errors: []int{400, 503}},
+	{method: "GET", path: "/api/v1/metrics", summary: "Get storage counters: config writes and writes saved by rolling-code reservation", tag: "metrics", response: Metrics{}},
+	{method: "GET", path: "/api/v1/backup", summary: "Export remotes, rolling codes, groups, schedules and tokens as a signed archive, encrypted with the X-Backup-Passphrase header if present (admin scope)", tag: "backup", response: backup.Archive{}, errors: []int{503}},
+	{method: "POST", path: "/api/v1/backup", summary: "Restore an archive (X-Backup-Passphrase header if encrypted); refused with 409 if a rolling code would go backwards (admin scope)", tag: "backup", filters: backupFilters, body: backup.Archive{}, response: config.RestoreReport{}, errors: []int{400, 409}},
+
+	{method: "POST", path: "/command", summary: "Send a command to a remote or a group (legacy)", tag: "legacy", body: CommandRequest{}, response: CommandResponse{}, errors: []int{400, 404, 500}},
+	{method: "GET", path: "/remotes", summary: "List remote names (legacy)", tag: "legacy", response: RemoteNameList{}},
+	{method: "GET", path: "/remote", summary: "Get a remote (legacy)", tag: "legacy", query: []string{"name"}, response: controller.RemoteState{}, errors: []int{400, 404}},
+	{method: "DELETE", path: "/remote", summary: "Delete a remote (legacy)", tag: "legacy", query: []string{"name"}, response: CommandResponse{}, errors: []int{400, 404, 409}},
+	{method: "POST", path: "/remote/add", summary: "Add or replace a remote (legacy)", tag: "legacy", body: remote.Control{}, response: CommandResponse{}, errors: []int{400}},
+	{method: "GET", path: "/groups", summary: "List group names (legacy)", tag: "legacy", response: GroupNameList{}},
+	{method: "GET", path: "/group", summary: "Get a group (legacy)", tag: "legacy", query: []string{"name"}, response: config.Group{}, errors: []int{400, 404}},
+	{method: "DELETE", path: "/group", summary: "Delete a group (legacy)", tag: "legacy", query: []string{"name"}, response: CommandResponse{}, errors: []int{400, 404}},
+	{method: "POST", path: "/group/add", summary: "Add or replace a group (legacy)", tag: "legacy", body: config.Group{}, response: CommandResponse{}, errors: []int{400}},
+	{method: "GET", path: "/schedules", summary: "List schedules (legacy)", tag: "legacy", response: ScheduleList{}},
+	{method: "GET", path: "/schedule", summary: "Get a schedule (legacy)", tag: "legacy", query: []string{"name"}, response: ScheduleInfo{}, errors: []int{400, 404}},
+	{method: "DELETE", path: "/schedule", summary: "Delete a schedule (legacy)", tag: "legacy", query: []string{"name"}, response: CommandResponse{}, errors: []int{400, 404}},
+	{method: "POST", path: "/schedule/add", summary: "Add or replace a schedule (legacy)", tag: "legacy", body: ScheduleRequest{}, response: ScheduleInfo{}, errors: []int{400}},
+	{method: "GET", path: "/events", summary: "Stream controller events (legacy)", tag: "legacy", response: controller.Event{}, stream: true},
+}
+
+// Document OpenAPI, construit une seule fois
+var (
+	openAPIOnce sync.Once
+	openAPIDoc  map[string]interface{}
+)
+
+// handleOpenAPI sert la description OpenAPI 3 de l'API
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	openAPIOnce.Do(func() { openAPIDoc = OpenAPI() })
+	sendJSONResponse(w, openAPIDoc)
+}
+
+// OpenAPI construit le document OpenAPI 3 de l'API. Les schémas sont déduits
+// par réflexion des types Go échangés par les handlers.
+func OpenAPI() map[string]interface{} {
+	schemas := make(map[string]interface{})
+	paths := make(map[string]map[string]interface{})
+
+	for _, op := range operations {
+		item, exists := paths[op.path]
+		if !exists {
+			item = make(map[string]interface{})
+			paths[op.path] = item
+		}
+		item[strings.ToLower(op.method)] = op.build(schemas)
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "RTS Commander API",
+			"version":     openAPIVersion,
+			"description": "Control Somfy RTS blinds through virtual remotes. Errors always use the CommandResponse schema with success=false.",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{
+					"type":        "http",
+					"scheme":      "bearer",
+					"description": "API token issued with --issue-token. Not required while no token is configured.",
+				},
+			},
+		},
+		"security": []interface{}{map[string]interface{}{"bearerAuth": []string{}}},
+	}
+}
+
+// Paramètres de chemin d'une route ({name}, {cmd})
+var pathParam = regexp.MustCompile(`\{(\w+)\}`)
+
+// build décrit une opération
+func (op operation) build(schemas map[string]interface{}) map[string]interface{} {
+	parameters := make([]interface{}, 0)
+	for _, match := range pathParam.FindAllStringSubmatch(op.path, -1) {
+		param := map[string]interface{}{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]interface{}{"type": "string"},
+		}
+		if match[1] == "cmd" {
+			names := append(remote.CommandNames(), controller.PositionCommand)
+			param["description"] = "Command name (aliases accepted, case-insensitive): " + strings.Join(names, ", ")
+		}
+		parameters = append(parameters, param)
+	}
+	for _, name := range op.query {
+		parameters = append(parameters, map[string]interface{}{
+			"name":     name,
+			"in":       "query",
+			"required": true,
+			"schema":   map[string]interface{}{"type": "string"},
+		})
+	}
+	filters := make([]string, 0, len(op.filters))
+	for name := range op.filters {
+		filters = append(filters, name)
+	}
+	sort.Strings(filters)
+	for _, name := range filters {
+		parameters = append(parameters, map[string]interface{}{
+			"name":        name,
+			"in":          "query",
+			"description": op.filters[name],
+			"schema":      map[string]interface{}{"type": "string"},
+		})
+	}
+	if op.stream {
+		parameters = append(parameters, map[string]interface{}{
+			"name":        "remote",
+			"in":          "query",
+			"description": "Only stream events of this remote",
+			"schema":      map[string]interface{}{"type": "string"},
+		})
+	}
+
+	status := op.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := map[string]interface{}{"description": http.StatusText(status)}
+	if op.response != nil && status != http.StatusNoContent {
+		mediaType := "application/json"
+		if op.stream {
+			mediaType = "text/event-stream"
+		}
+		success["content"] = map[string]interface{}{
+			mediaType: map[string]interface{}{"schema": schemaFor(reflect.TypeOf(op.response), schemas)},
+		}
+	}
+	responses := map[string]interface{}{statusKey(status): success}
+
+	errorSchema := schemaFor(reflect.TypeOf(CommandResponse{}), schemas)
+	for _, code := range op.errors {
+		responses[statusKey(code)] = map[string]interface{}{
+			"description": http.StatusText(code),
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": errorSchema},
+			},
+		}
+	}
+
+	if !publicPaths[op.path] {
+		for _, code := range []int{http.StatusUnauthorized, http.StatusForbidden} {
+			responses[statusKey(code)] = map[string]interface{}{
+				"description": http.StatusText(code),
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": errorSchema},
+				},
+			}
+		}
+	}
+
+	scope := requiredScope(op.method, op.path)
+	description := "Required token scope: " + scope + "."
+	if scope == config.ScopeCommand {
+		description += " A token restricted to some remotes gets 403 for the others."
+	}
+
+	built := map[string]interface{}{
+		"summary":     op.summary,
+		"description": description,
+		"operationId": operationID(op),
+		"tags":        []string{op.tag},
+		"parameters":  parameters,
+		"responses":   responses,
+	}
+	if op.body != nil {
+		built["requestBody"] = map[string]interface{}{
+			"required": !op.optional,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": schemaFor(reflect.TypeOf(op.body), schemas)},
+			},
+		}
+	}
+	return built
+}
+
+// statusKey convertit un statut HTTP en clé de réponse
+func statusKey(status int) string {
+	return strconv.Itoa(status)
+}
+
+// operationID construit un identifiant d'opération à partir de la méthode et du chemin
+func operationID(op operation) string {
+	var sb strings.Builder
+	sb.WriteString(strings.ToLower(op.method))
+	if op.tag == "legacy" {
+		sb.WriteString("Legacy")
+	}
+	for _, part := range strings.FieldsFunc(strings.TrimPrefix(op.path, "/api/v1"), func(r rune) bool {
+		return r == '/' || r == '{' || r == '}'
+	}) {
+		sb.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return sb.String()
+}
+
+// Type time.Time, décrit comme une chaîne date-time
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaFor retourne le schéma JSON d'un type Go ; les structures nommées sont
+// ajoutées aux composants et référencées
+func schemaFor(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return map[string]interface{}{"type": "string", "format": "byte"} // Base64, comme encoding/json
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		name, ok := schemaNames[t]
+		if !ok {
+			name = t.Name()
+		}
+		if _, exists := schemas[name]; !exists {
+			schemas[name] = nil // Réservé : évite une récursion infinie
+			schemas[name] = structSchema(t, schemas)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	case t.Kind() == reflect.Struct:
+		return structSchema(t, schemas)
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint8:
+		return map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 0xFF}
+	case reflect.Uint16:
+		return map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 0xFFFF}
+	case reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaFor(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaFor(t.Elem(), schemas)}
+	}
+	return map[string]interface{}{}
+}
+
+// structSchema décrit les champs JSON d'une structure ; les structures
+// incluses sans nom de champ JSON sont aplaties, comme le fait encoding/json
+func structSchema(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := field.Tag.Get("json")
+			if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+				walk(field.Type)
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+
+			properties[name] = schemaFor(field.Type, schemas)
+			if !strings.Contains(opts, "omitempty") && field.Type.Kind() != reflect.Ptr {
+				required = append(required, name)
+			}
+		}
+	}
+	walk(t)
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
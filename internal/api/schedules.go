@@ -0,0 +1,157 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"rtscommander/m/internal/config"
+	"rtscommander/m/internal/scheduler"
+)
+
+// ScheduleRequest représente une programmation à ajouter, décrite en JSON ou
+// par une expression textuelle (ex. "weekdays 07:30 up salon")
+type ScheduleRequest struct {
+	config.Schedule
+	Spec string `json:"spec,omitempty"`
+}
+
+// ScheduleInfo représente une programmation et sa prochaine exécution
+type ScheduleInfo struct {
+	config.Schedule
+	NextRun *time.Time `json:"next_run,omitempty"`
+}
+
+// ScheduleList représente la liste des programmations
+type ScheduleList struct {
+	Schedules []ScheduleInfo `json:"schedules"`
+	Count     int            `json:"count"`
+	Timezone  string         `json:"timezone"` // Fuseau par défaut ("" = heure locale du système)
+}
+
+// scheduleInfo complète une programmation avec sa prochaine exécution
+func (s *Server) scheduleInfo(sc config.Schedule) ScheduleInfo {
+	info := ScheduleInfo{Schedule: sc}
+	if next, ok := scheduler.NextRun(s.ctrl.Config(), sc, time.Now()); ok {
+		info.NextRun = &next
+	}
+	return info
+}
+
+// handleListSchedules liste toutes les programmations
+func (s *Server) handleListSchedules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cfg := s.ctrl.Config()
+	schedules := make([]ScheduleInfo, 0)
+	for _, name := range cfg.ListSchedules() {
+		if sc, exists := cfg.GetSchedule(name); exists {
+			schedules = append(schedules, s.scheduleInfo(sc))
+		}
+	}
+
+	sendJSONResponse(w, ScheduleList{Schedules: schedules, Count: len(schedules), Timezone: cfg.Timezone()})
+}
+
+// handleSchedule obtient (GET) ou supprime (DELETE) une programmation
+func (s *Server) handleSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		sendJSONError(w, "Missing 'name' parameter", http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		if err := s.ctrl.Config().RemoveSchedule(name); err != nil {
+			sendJSONError(w, err.Error(), errorStatus(err, http.StatusInternalServerError))
+			return
+		}
+		sendJSONResponse(w, CommandResponse{
+			Success: true,
+			Message: fmt.Sprintf("Schedule '%s' deleted", name),
+		})
+		return
+	}
+
+	sc, exists := s.ctrl.Config().GetSchedule(name)
+	if !exists {
+		sendJSONError(w, fmt.Sprintf("Schedule '%s' not found", name), http.StatusNotFound)
+		return
+	}
+	sendJSONResponse(w, s.scheduleInfo(sc))
+}
+
+// handleAddSchedule ajoute ou remplace une programmation
+func (s *Server) handleAddSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req ScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	saved, err := s.saveSchedule(req)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendJSONResponse(w, s.scheduleInfo(saved))
+}
+
+// saveSchedule valide puis ajoute ou remplace une programmation
+func (s *Server) saveSchedule(req ScheduleRequest) (config.Schedule, error) {
+	sc, err := s.buildSchedule(req)
+	if err != nil {
+		return config.Schedule{}, err
+	}
+	return s.storeSchedule(sc)
+}
+
+// buildSchedule lit et valide la programmation d'une requête, nommée d'après
+// sa description si elle n'a pas de nom
+func (s *Server) buildSchedule(req ScheduleRequest) (config.Schedule, error) {
+	sc := req.Schedule
+	if req.Spec != "" {
+		parsed, err := scheduler.Parse(req.Spec)
+		if err != nil {
+			return config.Schedule{}, err
+		}
+		if sc.Name != "" {
+			parsed.Name = sc.Name
+		}
+		parsed.Timezone = sc.Timezone
+		parsed.Disabled = sc.Disabled
+		sc = parsed
+	} else if err := scheduler.Validate(sc); err != nil {
+		return config.Schedule{}, err
+	}
+	sc.LastRun = nil
+
+	if err := scheduler.CheckSite(s.ctrl.Config(), sc); err != nil {
+		return config.Schedule{}, err
+	}
+	return sc, nil
+}
+
+// storeSchedule ajoute ou remplace une programmation validée
+func (s *Server) storeSchedule(sc config.Schedule) (config.Schedule, error) {
+	if err := s.ctrl.Config().AddSchedule(sc); err != nil {
+		return config.Schedule{}, err
+	}
+
+	saved, _ := s.ctrl.Config().GetSchedule(sc.Name)
+	return saved, nil
+}
@@ -2,35 +2,103 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 
+	"rtscommander/m/internal/config"
 	"rtscommander/m/internal/controller"
 	"rtscommander/m/internal/remote"
 )
 
 // CommandRequest représente une requête de commande
 type CommandRequest struct {
-	Remote  string `json:"remote"`
+	Remote  string `json:"remote,omitempty"`
+	Group   string `json:"group,omitempty"` // Groupe de télécommandes (à la place de remote)
 	Command string `json:"command"`
+	CommandOptions
+}
+
+// CommandOptions précise l'appui ou la position d'une commande
+type CommandOptions struct {
+	HoldMs  int  `json:"hold_ms,omitempty"` // Durée d'appui simulée (ms)
+	Repeats int  `json:"repeats,omitempty"` // Nombre de répétitions explicite
+	Value   *int `json:"value,omitempty"`   // Position cible (commande "position")
 }
 
 // CommandResponse représente une réponse de commande
 type CommandResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-	Remote  string `json:"remote,omitempty"`
+	Success bool                      `json:"success"`
+	Message string                    `json:"message"`
+	Remote  string                    `json:"remote,omitempty"`
+	Group   string                    `json:"group,omitempty"`
+	Results []controller.MemberResult `json:"results,omitempty"` // Résultat par membre d'un groupe
+}
+
+// options retourne les options d'envoi demandées pour une commande, après
+// vérification de leurs limites
+func (req *CommandRequest) options(cmd remote.Command) (controller.SendOptions, error) {
+	if req.HoldMs < 0 || req.Repeats < 0 {
+		return controller.SendOptions{}, fmt.Errorf("%w: hold_ms and repeats must not be negative", controller.ErrInvalidOptions)
+	}
+	opts := controller.SendOptions{Repeats: cmd.Repeats}
+	if req.Repeats > 0 {
+		opts.Repeats = req.Repeats
+	}
+	if req.HoldMs > 0 {
+		opts.Hold = time.Duration(req.HoldMs) * time.Millisecond
+	}
+	return opts, opts.Validate()
+}
+
+// RemoteNameList représente la liste des noms de télécommandes (ancien endpoint /remotes)
+type RemoteNameList struct {
+	Remotes []string `json:"remotes"`
+	Count   int      `json:"count"`
 }
 
 // Server représente le serveur HTTP
 type Server struct {
 	ctrl *controller.Controller
+	mux  *http.ServeMux
+
+	backupMu      sync.Mutex
+	backupKeyFile string // Fichier de la clé de signature des sauvegardes en clair
+	backupKey     []byte // Clé lue (ou créée) à la première sauvegarde ou restauration
 }
 
 // NewServer crée un nouveau serveur API
 func NewServer(ctrl *controller.Controller) *Server {
-	return &Server{ctrl: ctrl}
+	s := &Server{ctrl: ctrl, mux: http.NewServeMux()}
+	s.routes()
+	return s
+}
+
+// Handler retourne le gestionnaire HTTP de l'API, authentification comprise
+func (s *Server) Handler() http.Handler {
+	return s.authenticate(s.mux)
+}
+
+// routes enregistre l'API v1 et les anciens endpoints, conservés comme alias
+func (s *Server) routes() {
+	s.routesV1()
+
+	s.mux.HandleFunc("/command", s.handleCommand)
+	s.mux.HandleFunc("/remotes", s.handleListRemotes)
+	s.mux.HandleFunc("/remote", s.handleGetRemote)
+	s.mux.HandleFunc("/remote/add", s.handleAddRemote)
+	s.mux.HandleFunc("/groups", s.handleListGroups)
+	s.mux.HandleFunc("/group", s.handleGroup)
+	s.mux.HandleFunc("/group/add", s.handleAddGroup)
+	s.mux.HandleFunc("/schedules", s.handleListSchedules)
+	s.mux.HandleFunc("/schedule", s.handleSchedule)
+	s.mux.HandleFunc("/schedule/add", s.handleAddSchedule)
+	s.mux.HandleFunc("/events", s.handleEvents)
+	s.mux.HandleFunc("/openapi.json", s.handleOpenAPI)
 }
 
 // handleCommand gère les requêtes d'envoi de commande
@@ -46,25 +114,52 @@ func (s *Server) handleCommand(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Convertir la commande string en byte
-	var cmdByte byte
-	switch req.Command {
-	case "up", "UP", "monter":
-		cmdByte = remote.CmdUp
-	case "down", "DOWN", "descendre":
-		cmdByte = remote.CmdDown
-	case "my", "MY", "stop", "STOP":
-		cmdByte = remote.CmdMy
-	case "prog", "PROG", "program":
-		cmdByte = remote.CmdProg
-	default:
+	s.runCommand(w, r, &req)
+}
+
+// runCommand exécute une commande sur une télécommande ou un groupe, au nom du
+// jeton de la requête
+func (s *Server) runCommand(w http.ResponseWriter, r *http.Request, req *CommandRequest) {
+	// Commande de groupe
+	if req.Group != "" {
+		s.handleGroupCommand(w, r, req)
+		return
+	}
+
+	// Déplacement vers une position : exécuté en arrière-plan
+	if strings.EqualFold(req.Command, controller.PositionCommand) {
+		if req.Value == nil {
+			sendJSONError(w, "Missing 'value' for position command", http.StatusBadRequest)
+			return
+		}
+		if _, err := s.session(r).StartPosition(req.Remote, *req.Value); err != nil {
+			sendJSONError(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+			return
+		}
+		sendJSONResponse(w, CommandResponse{
+			Success: true,
+			Message: fmt.Sprintf("Moving '%s' to %d%%", req.Remote, *req.Value),
+			Remote:  req.Remote,
+		})
+		return
+	}
+
+	// Retrouver la commande
+	cmd, ok := remote.ParseCommand(req.Command)
+	if !ok {
 		sendJSONError(w, fmt.Sprintf("Unknown command: %s", req.Command), http.StatusBadRequest)
 		return
 	}
 
-	// Envoyer la commande
-	if err := s.ctrl.SendCommand(req.Remote, cmdByte); err != nil {
-		sendJSONError(w, err.Error(), http.StatusInternalServerError)
+	opts, err := req.options(cmd)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Envoyer la commande (appui long éventuel)
+	if err := s.session(r).SendCommandWithOptions(req.Remote, cmd.Code, opts); err != nil {
+		sendJSONError(w, err.Error(), errorStatus(err, http.StatusInternalServerError))
 		return
 	}
 
@@ -82,17 +177,18 @@ func (s *Server) handleListRemotes(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	remotes := s.ctrl.Config().ListRemotes()
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"remotes": remotes,
-		"count":   len(remotes),
-	})
+	remotes := make([]string, 0)
+	for _, name := range s.ctrl.Config().ListRemotes() {
+		if canSee(r, name) {
+			remotes = append(remotes, name)
+		}
+	}
+	sendJSONResponse(w, RemoteNameList{Remotes: remotes, Count: len(remotes)})
 }
 
-// handleGetRemote obtient les détails d'une télécommande
+// handleGetRemote obtient (GET) ou supprime (DELETE) une télécommande
 func (s *Server) handleGetRemote(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
+	if r.Method != http.MethodGet && r.Method != http.MethodDelete {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
@@ -103,15 +199,28 @@ func (s *Server) handleGetRemote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	remote, exists := s.ctrl.Config().GetRemote(name)
+	if r.Method == http.MethodDelete {
+		if err := s.ctrl.RemoveRemote(name); err != nil {
+			sendJSONError(w, err.Error(), errorStatus(err, http.StatusBadRequest))
+			return
+		}
+		sendJSONResponse(w, CommandResponse{
+			Success: true,
+			Message: fmt.Sprintf("Remote '%s' deleted", name),
+			Remote:  name,
+		})
+		return
+	}
 
-	if !exists {
+	state, exists := s.ctrl.State(name)
+
+	if !exists || !canSee(r, name) {
 		sendJSONError(w, fmt.Sprintf("Remote '%s' not found", name), http.StatusNotFound)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(remote)
+	json.NewEncoder(w).Encode(visibleState(r, state))
 }
 
 // handleAddRemote ajoute une nouvelle télécommande
@@ -132,7 +241,7 @@ func (s *Server) handleAddRemote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.ctrl.Config().AddRemote(rc.Name, &rc); err != nil {
+	if err := s.ctrl.AddRemote(&rc); err != nil {
 		sendJSONError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -160,19 +269,59 @@ func sendJSONError(w http.ResponseWriter, message string, status int) {
 	})
 }
 
+// errorStatus retourne le statut HTTP correspondant à une erreur, ou fallback
+// si sa cause n'est pas identifiée
+func errorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, config.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, config.ErrExists), errors.Is(err, config.ErrInUse):
+		return http.StatusConflict
+	case errors.Is(err, controller.ErrAccessDenied):
+		return http.StatusForbidden
+	case errors.Is(err, controller.ErrInvalidOptions):
+		return http.StatusBadRequest
+	}
+	return fallback
+}
+
 // Start démarre le serveur HTTP
 func (s *Server) Start(addr string) error {
-	http.HandleFunc("/command", s.handleCommand)
-	http.HandleFunc("/remotes", s.handleListRemotes)
-	http.HandleFunc("/remote", s.handleGetRemote)
-	http.HandleFunc("/remote/add", s.handleAddRemote)
+	s.logStartup("HTTP", addr)
+	return http.ListenAndServe(addr, s.Handler())
+}
 
-	log.Printf("HTTP server starting on %s", addr)
+// logStartup affiche l'adresse d'écoute et la liste des endpoints
+func (s *Server) logStartup(protocol, addr string) {
+	log.Printf("%s server starting on %s", protocol, addr)
+	if !s.ctrl.Config().HasTokens() {
+		log.Println("Warning: no API token configured, the API is open to anyone on the network (see --issue-token)")
+	}
 	log.Println("Endpoints:")
-	log.Println("  POST   /command       - Send a command")
-	log.Println("  GET    /remotes       - List all remotes")
-	log.Println("  GET    /remote?name=X - Get remote details")
-	log.Println("  POST   /remote/add    - Add a new remote")
-
-	return http.ListenAndServe(addr, nil)
+	log.Println("  GET    /api/v1/remotes                        - List all remotes")
+	log.Println("  POST   /api/v1/remotes                        - Create a remote")
+	log.Println("  GET    /api/v1/remotes/{name}                 - Get remote details")
+	log.Println("  PUT    /api/v1/remotes/{name}                 - Create or replace a remote")
+	log.Println("  PATCH  /api/v1/remotes/{name}                 - Update or rename a remote")
+	log.Println("  DELETE /api/v1/remotes/{name}                 - Delete a remote")
+	log.Println("  POST   /api/v1/remotes/{name}/commands/{cmd}  - Send a command")
+	log.Println("  GET    /api/v1/groups                         - List all groups")
+	log.Println("  POST   /api/v1/groups                         - Create a group")
+	log.Println("  GET    /api/v1/groups/{name}                  - Get group details")
+	log.Println("  PUT    /api/v1/groups/{name}                  - Create or replace a group")
+	log.Println("  DELETE /api/v1/groups/{name}                  - Delete a group")
+	log.Println("  POST   /api/v1/groups/{name}/commands/{cmd}   - Send a command to a group")
+	log.Println("  GET    /api/v1/schedules                      - List all schedules")
+	log.Println("  POST   /api/v1/schedules                      - Create a schedule")
+	log.Println("  GET    /api/v1/schedules/{name}               - Get schedule details")
+	log.Println("  PUT    /api/v1/schedules/{name}               - Create or replace a schedule")
+	log.Println("  DELETE /api/v1/schedules/{name}               - Delete a schedule")
+	log.Println("  GET    /api/v1/events                         - Stream events (Server-Sent Events)")
+	log.Println("  GET    /api/v1/history                        - Query the audit log (?remote=&since=)")
+	log.Println("  GET    /api/v1/metrics                        - Config write counters")
+	log.Println("  GET    /api/v1/backup                         - Export a signed backup archive")
+	log.Println("  POST   /api/v1/backup                         - Restore a backup archive (?force=true)")
+	log.Println("  GET    /openapi.json                          - OpenAPI 3 description of the API")
+	log.Println("Legacy aliases: /command, /remotes, /remote, /remote/add, /groups, /group,")
+	log.Println("  /group/add, /schedules, /schedule, /schedule/add, /events")
 }
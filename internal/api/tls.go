@@ -0,0 +1,128 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// TLSOptions décrit le certificat du serveur et la vérification des
+// certificats clients (TLS mutuel)
+type TLSOptions struct {
+	CertFile          string
+	KeyFile           string
+	ClientCAFile      string // Autorité des certificats clients ; active le TLS mutuel
+	RequireClientCert bool   // Refuser les clients sans certificat ; sinon vérifié s'il est présenté
+}
+
+// tlsStore conserve le certificat et l'autorité cliente en cours, rechargés
+// sans interrompre le serveur
+type tlsStore struct {
+	opts TLSOptions
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+// load lit le certificat, la clé et l'autorité cliente éventuelle. En cas
+// d'erreur, les fichiers précédemment chargés restent en service.
+func (st *tlsStore) load() error {
+	cert, err := tls.LoadX509KeyPair(st.opts.CertFile, st.opts.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load certificate: %v", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if st.opts.ClientCAFile != "" {
+		data, err := os.ReadFile(st.opts.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA: %v", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(data) {
+			return fmt.Errorf("no certificate found in client CA %s", st.opts.ClientCAFile)
+		}
+	}
+
+	st.mu.Lock()
+	st.cert = &cert
+	st.clientCAs = clientCAs
+	st.mu.Unlock()
+	return nil
+}
+
+// config retourne la configuration TLS du serveur : chaque connexion utilise
+// les fichiers chargés au moment de la poignée de main. La configuration de
+// chaque connexion est une copie de la configuration de base, protocoles ALPN
+// (HTTP/2) compris.
+func (st *tlsStore) config() *tls.Config {
+	clientAuth := tls.NoClientCert
+	if st.opts.ClientCAFile != "" {
+		clientAuth = tls.VerifyClientCertIfGiven
+		if st.opts.RequireClientCert {
+			clientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	base := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+		ClientAuth: clientAuth,
+	}
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		st.mu.RLock()
+		defer st.mu.RUnlock()
+
+		config := base.Clone()
+		config.GetConfigForClient = nil
+		config.Certificates = []tls.Certificate{*st.cert}
+		config.ClientCAs = st.clientCAs
+		return config, nil
+	}
+	return base
+}
+
+// reloadOnSignal recharge les certificats à chaque SIGHUP
+func (st *tlsStore) reloadOnSignal() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	for range signals {
+		if err := st.load(); err != nil {
+			log.Printf("Warning: TLS reload failed, keeping the current certificate: %v", err)
+			continue
+		}
+		log.Printf("TLS certificate reloaded from %s", st.opts.CertFile)
+	}
+}
+
+// StartTLS démarre le serveur HTTPS ; les certificats sont rechargés sur SIGHUP
+func (s *Server) StartTLS(addr string, opts TLSOptions) error {
+	store := &tlsStore{opts: opts}
+	if err := store.load(); err != nil {
+		return err
+	}
+	go store.reloadOnSignal()
+
+	s.logStartup("HTTPS", addr)
+	switch {
+	case opts.ClientCAFile != "" && opts.RequireClientCert:
+		log.Printf("Client certificates required (CA: %s)", opts.ClientCAFile)
+	case opts.ClientCAFile != "":
+		log.Printf("Client certificates verified when presented (CA: %s)", opts.ClientCAFile)
+	}
+
+	server := &http.Server{
+		Addr:      addr,
+		Handler:   s.Handler(),
+		TLSConfig: store.config(),
+	}
+	return server.ListenAndServeTLS("", "")
+}
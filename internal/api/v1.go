@@ -0,0 +1,517 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"rtscommander/m/internal/config"
+	"rtscommander/m/internal/controller"
+	"rtscommander/m/internal/remote"
+)
+
+// Préfixe des routes de l'API v1
+const apiV1 = "/api/v1"
+
+// RemoteList représente la liste des télécommandes et de leur état
+type RemoteList struct {
+	Remotes []*controller.RemoteState `json:"remotes"`
+	Count   int                       `json:"count"`
+}
+
+// GroupList représente la liste des groupes
+type GroupList struct {
+	Groups []config.Group `json:"groups"`
+	Count  int            `json:"count"`
+}
+
+// RemotePatch décrit une modification partielle de télécommande : seuls les
+// champs présents sont modifiés, name renomme la télécommande
+type RemotePatch struct {
+	Name          *string `json:"name,omitempty"`
+	Address       *uint32 `json:"address,omitempty"`
+	RollingCode   *uint16 `json:"rolling_code,omitempty"`
+	EncryptionKey *byte   `json:"encryption_key,omitempty"`
+	TravelUpMs    *int    `json:"travel_up_ms,omitempty"`
+	TravelDownMs  *int    `json:"travel_down_ms,omitempty"`
+	MyPosition    *int    `json:"my_position,omitempty"`
+	Position      *int    `json:"position,omitempty"`
+}
+
+// validate vérifie les valeurs d'une modification
+func (p *RemotePatch) validate() error {
+	if p.Name != nil && *p.Name == "" {
+		return fmt.Errorf("missing remote name")
+	}
+	for field, pos := range map[string]*int{"my_position": p.MyPosition, "position": p.Position} {
+		if pos != nil && (*pos < controller.PositionClosed || *pos > controller.PositionOpen) {
+			return fmt.Errorf("invalid %s %d (expected %d-%d)", field, *pos, controller.PositionClosed, controller.PositionOpen)
+		}
+	}
+	for field, ms := range map[string]*int{"travel_up_ms": p.TravelUpMs, "travel_down_ms": p.TravelDownMs} {
+		if ms != nil && *ms < 0 {
+			return fmt.Errorf("invalid %s %d", field, *ms)
+		}
+	}
+	return nil
+}
+
+// apply reporte les champs présents sur une télécommande
+func (p *RemotePatch) apply(rc *remote.Control) {
+	if p.Address != nil {
+		rc.Address = *p.Address
+	}
+	if p.RollingCode != nil {
+		rc.RollingCode = *p.RollingCode
+	}
+	if p.EncryptionKey != nil {
+		rc.EncryptionKey = *p.EncryptionKey
+	}
+	if p.TravelUpMs != nil {
+		rc.TravelUpMs = *p.TravelUpMs
+	}
+	if p.TravelDownMs != nil {
+		rc.TravelDownMs = *p.TravelDownMs
+	}
+	if p.MyPosition != nil {
+		rc.MyPosition = p.MyPosition
+	}
+	if p.Position != nil {
+		rc.Position = p.Position
+	}
+}
+
+// routesV1 enregistre les routes de l'API v1
+func (s *Server) routesV1() {
+	s.mux.HandleFunc(apiV1+"/remotes", methods(map[string]http.HandlerFunc{
+		http.MethodGet:  s.handleV1ListRemotes,
+		http.MethodPost: s.handleV1CreateRemote,
+	}))
+	s.mux.HandleFunc(apiV1+"/remotes/{name}", methods(map[string]http.HandlerFunc{
+		http.MethodGet:    s.handleV1GetRemote,
+		http.MethodPut:    s.handleV1PutRemote,
+		http.MethodPatch:  s.handleV1PatchRemote,
+		http.MethodDelete: s.handleV1DeleteRemote,
+	}))
+	s.mux.HandleFunc(apiV1+"/remotes/{name}/commands/{cmd}", methods(map[string]http.HandlerFunc{
+		http.MethodPost: s.handleV1RemoteCommand,
+	}))
+
+	s.mux.HandleFunc(apiV1+"/groups", methods(map[string]http.HandlerFunc{
+		http.MethodGet:  s.handleV1ListGroups,
+		http.MethodPost: s.handleV1CreateGroup,
+	}))
+	s.mux.HandleFunc(apiV1+"/groups/{name}", methods(map[string]http.HandlerFunc{
+		http.MethodGet:    s.handleV1GetGroup,
+		http.MethodPut:    s.handleV1PutGroup,
+		http.MethodDelete: s.handleV1DeleteGroup,
+	}))
+	s.mux.HandleFunc(apiV1+"/groups/{name}/commands/{cmd}", methods(map[string]http.HandlerFunc{
+		http.MethodPost: s.handleV1GroupCommand,
+	}))
+
+	s.mux.HandleFunc(apiV1+"/schedules", methods(map[string]http.HandlerFunc{
+		http.MethodGet:  s.handleListSchedules,
+		http.MethodPost: s.handleV1CreateSchedule,
+	}))
+	s.mux.HandleFunc(apiV1+"/schedules/{name}", methods(map[string]http.HandlerFunc{
+		http.MethodGet:    s.handleV1GetSchedule,
+		http.MethodPut:    s.handleV1PutSchedule,
+		http.MethodDelete: s.handleV1DeleteSchedule,
+	}))
+
+	s.mux.HandleFunc(apiV1+"/events", methods(map[string]http.HandlerFunc{
+		http.MethodGet: s.handleEvents,
+	}))
+	s.mux.HandleFunc(apiV1+"/history", methods(map[string]http.HandlerFunc{
+		http.MethodGet: s.handleHistory,
+	}))
+	s.mux.HandleFunc(apiV1+"/metrics", methods(map[string]http.HandlerFunc{
+		http.MethodGet: s.handleMetrics,
+	}))
+	s.mux.HandleFunc(apiV1+"/backup", methods(map[string]http.HandlerFunc{
+		http.MethodGet:  s.handleBackup,
+		http.MethodPost: s.handleRestore,
+	}))
+
+	// Toute autre route de l'API v1 répond par une erreur JSON
+	s.mux.HandleFunc(apiV1+"/", func(w http.ResponseWriter, r *http.Request) {
+		sendJSONError(w, fmt.Sprintf("No route for %s", r.URL.Path), http.StatusNotFound)
+	})
+}
+
+// methods aiguille une requête selon sa méthode HTTP, avec une erreur JSON 405
+// pour les méthodes non prises en charge
+func methods(handlers map[string]http.HandlerFunc) http.HandlerFunc {
+	allowed := make([]string, 0, len(handlers))
+	for method := range handlers {
+		allowed = append(allowed, method)
+	}
+	sort.Strings(allowed)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if handler, ok := handlers[r.Method]; ok {
+			handler(w, r)
+			return
+		}
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		sendJSONError(w, fmt.Sprintf("Method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+	}
+}
+
+// sendJSONStatus envoie une réponse JSON avec un statut explicite
+func sendJSONStatus(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+// sendCreated envoie la ressource créée (201) avec son URL, ou la ressource
+// remplacée (200)
+func sendCreated(w http.ResponseWriter, created bool, collection, name string, data interface{}) {
+	if !created {
+		sendJSONResponse(w, data)
+		return
+	}
+	w.Header().Set("Location", apiV1+"/"+collection+"/"+url.PathEscape(name))
+	sendJSONStatus(w, http.StatusCreated, data)
+}
+
+// decodeBody décode le corps JSON d'une requête, ou répond par une erreur 400.
+// Un corps vide est accepté si optional est vrai.
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}, optional bool) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err == io.EOF && optional {
+		return true
+	}
+	if err != nil {
+		sendJSONError(w, "Invalid request body", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// handleV1ListRemotes liste les télécommandes avec leur état
+func (s *Server) handleV1ListRemotes(w http.ResponseWriter, r *http.Request) {
+	names := s.ctrl.Config().ListRemotes()
+	sort.Strings(names)
+
+	remotes := make([]*controller.RemoteState, 0, len(names))
+	for _, name := range names {
+		if !canSee(r, name) {
+			continue
+		}
+		if state, exists := s.ctrl.State(name); exists {
+			remotes = append(remotes, visibleState(r, state))
+		}
+	}
+	sendJSONResponse(w, RemoteList{Remotes: remotes, Count: len(remotes)})
+}
+
+// handleV1CreateRemote crée une télécommande dont le nom n'est pas encore utilisé
+func (s *Server) handleV1CreateRemote(w http.ResponseWriter, r *http.Request) {
+	var rc remote.Control
+	if !decodeBody(w, r, &rc, false) {
+		return
+	}
+	if rc.Name == "" {
+		sendJSONError(w, "Missing remote name", http.StatusBadRequest)
+		return
+	}
+	if _, exists := s.ctrl.Config().Snapshot(rc.Name); exists {
+		sendJSONError(w, fmt.Sprintf("Remote '%s' already exists", rc.Name), http.StatusConflict)
+		return
+	}
+
+	s.saveRemote(w, &rc, true)
+}
+
+// handleV1GetRemote retourne une télécommande et sa position estimée
+func (s *Server) handleV1GetRemote(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	state, exists := s.ctrl.State(name)
+	if !exists || !canSee(r, name) {
+		sendJSONError(w, fmt.Sprintf("Remote '%s' not found", name), http.StatusNotFound)
+		return
+	}
+	sendJSONResponse(w, visibleState(r, state))
+}
+
+// handleV1PutRemote crée ou remplace une télécommande. Adresse, rolling code et
+// clé absents du corps sont conservés : sans cela, les moteurs appairés
+// ignoreraient les commandes suivantes.
+func (s *Server) handleV1PutRemote(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	var body map[string]json.RawMessage
+	if !decodeBody(w, r, &body, false) {
+		return
+	}
+	data, _ := json.Marshal(body)
+	var rc remote.Control
+	if err := json.Unmarshal(data, &rc); err != nil {
+		sendJSONError(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if rc.Name != "" && rc.Name != name {
+		sendJSONError(w, "Remote name does not match the URL, use PATCH to rename", http.StatusBadRequest)
+		return
+	}
+	rc.Name = name
+
+	if _, exists := s.ctrl.Config().Snapshot(name); !exists {
+		s.saveRemote(w, &rc, true)
+		return
+	}
+
+	// Remplacement sous le verrou des émissions : le rolling code conservé est
+	// celui de la dernière trame émise
+	err := s.ctrl.UpdateRemote(name, func(stored *remote.Control) {
+		replaced := rc
+		if _, set := body["address"]; !set {
+			replaced.Address = stored.Address
+		}
+		if _, set := body["rolling_code"]; !set {
+			replaced.RollingCode = stored.RollingCode
+		}
+		if _, set := body["encryption_key"]; !set {
+			replaced.EncryptionKey = stored.EncryptionKey
+		}
+		*stored = replaced
+	})
+	if err != nil {
+		sendJSONError(w, err.Error(), errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+	state, _ := s.ctrl.State(name)
+	sendJSONResponse(w, state)
+}
+
+// saveRemote enregistre une télécommande et renvoie son état
+func (s *Server) saveRemote(w http.ResponseWriter, rc *remote.Control, created bool) {
+	if err := s.ctrl.AddRemote(rc); err != nil {
+		sendJSONError(w, err.Error(), errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+	state, _ := s.ctrl.State(rc.Name)
+	sendCreated(w, created, "remotes", rc.Name, state)
+}
+
+// handleV1PatchRemote modifie une partie des champs d'une télécommande,
+// éventuellement en la renommant
+func (s *Server) handleV1PatchRemote(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	var patch RemotePatch
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&patch); err != nil {
+		sendJSONError(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err := patch.validate(); err != nil {
+		sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Renommage et modification appliqués ensemble, ou pas du tout
+	newName := name
+	if patch.Name != nil {
+		newName = *patch.Name
+	}
+	if err := s.ctrl.PatchRemote(name, newName, patch.apply); err != nil {
+		sendJSONError(w, err.Error(), errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+	name = newName
+
+	state, _ := s.ctrl.State(name)
+	sendJSONResponse(w, state)
+}
+
+// handleV1DeleteRemote supprime une télécommande
+func (s *Server) handleV1DeleteRemote(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if err := s.ctrl.RemoveRemote(name); err != nil {
+		sendJSONError(w, err.Error(), errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleV1RemoteCommand envoie une commande à une télécommande. Le corps
+// (optionnel) précise hold_ms, repeats ou value.
+func (s *Server) handleV1RemoteCommand(w http.ResponseWriter, r *http.Request) {
+	var opts CommandOptions
+	if !decodeBody(w, r, &opts, true) {
+		return
+	}
+
+	s.runCommand(w, r, &CommandRequest{Remote: r.PathValue("name"), Command: r.PathValue("cmd"), CommandOptions: opts})
+}
+
+// handleV1ListGroups liste les groupes
+func (s *Server) handleV1ListGroups(w http.ResponseWriter, r *http.Request) {
+	cfg := s.ctrl.Config()
+	groups := make([]config.Group, 0)
+	for _, name := range cfg.ListGroups() {
+		if group, exists := cfg.GetGroup(name); exists {
+			groups = append(groups, group)
+		}
+	}
+	sendJSONResponse(w, GroupList{Groups: groups, Count: len(groups)})
+}
+
+// handleV1CreateGroup crée un groupe dont le nom n'est pas encore utilisé
+func (s *Server) handleV1CreateGroup(w http.ResponseWriter, r *http.Request) {
+	var group config.Group
+	if !decodeBody(w, r, &group, false) {
+		return
+	}
+	if group.Name == "" {
+		sendJSONError(w, "Missing group name", http.StatusBadRequest)
+		return
+	}
+	if _, exists := s.ctrl.Config().GetGroup(group.Name); exists {
+		sendJSONError(w, fmt.Sprintf("Group '%s' already exists", group.Name), http.StatusConflict)
+		return
+	}
+
+	s.saveGroup(w, group, true)
+}
+
+// handleV1GetGroup retourne un groupe
+func (s *Server) handleV1GetGroup(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	group, exists := s.ctrl.Config().GetGroup(name)
+	if !exists {
+		sendJSONError(w, fmt.Sprintf("Group '%s' not found", name), http.StatusNotFound)
+		return
+	}
+	sendJSONResponse(w, group)
+}
+
+// handleV1PutGroup crée ou remplace un groupe
+func (s *Server) handleV1PutGroup(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	var group config.Group
+	if !decodeBody(w, r, &group, false) {
+		return
+	}
+	if group.Name != "" && group.Name != name {
+		sendJSONError(w, "Group name does not match the URL", http.StatusBadRequest)
+		return
+	}
+	group.Name = name
+
+	_, exists := s.ctrl.Config().GetGroup(name)
+	s.saveGroup(w, group, !exists)
+}
+
+// saveGroup enregistre un groupe et le renvoie
+func (s *Server) saveGroup(w http.ResponseWriter, group config.Group, created bool) {
+	if err := s.ctrl.Config().AddGroup(group); err != nil {
+		sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	saved, _ := s.ctrl.Config().GetGroup(group.Name)
+	sendCreated(w, created, "groups", group.Name, saved)
+}
+
+// handleV1DeleteGroup supprime un groupe
+func (s *Server) handleV1DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	if err := s.ctrl.Config().RemoveGroup(r.PathValue("name")); err != nil {
+		sendJSONError(w, err.Error(), errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleV1GroupCommand envoie une commande à un groupe
+func (s *Server) handleV1GroupCommand(w http.ResponseWriter, r *http.Request) {
+	var opts CommandOptions
+	if !decodeBody(w, r, &opts, true) {
+		return
+	}
+
+	s.runCommand(w, r, &CommandRequest{Group: r.PathValue("name"), Command: r.PathValue("cmd"), CommandOptions: opts})
+}
+
+// handleV1CreateSchedule crée une programmation dont le nom n'est pas encore utilisé
+func (s *Server) handleV1CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	var req ScheduleRequest
+	if !decodeBody(w, r, &req, false) {
+		return
+	}
+
+	// Conflit vérifié sur le nom final, généré depuis spec s'il est absent
+	sc, err := s.buildSchedule(req)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, exists := s.ctrl.Config().GetSchedule(sc.Name); exists {
+		sendJSONError(w, fmt.Sprintf("Schedule '%s' already exists", sc.Name), http.StatusConflict)
+		return
+	}
+
+	saved, err := s.storeSchedule(sc)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendCreated(w, true, "schedules", saved.Name, s.scheduleInfo(saved))
+}
+
+// handleV1GetSchedule retourne une programmation et sa prochaine exécution
+func (s *Server) handleV1GetSchedule(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	sc, exists := s.ctrl.Config().GetSchedule(name)
+	if !exists {
+		sendJSONError(w, fmt.Sprintf("Schedule '%s' not found", name), http.StatusNotFound)
+		return
+	}
+	sendJSONResponse(w, s.scheduleInfo(sc))
+}
+
+// handleV1PutSchedule crée ou remplace une programmation
+func (s *Server) handleV1PutSchedule(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	var req ScheduleRequest
+	if !decodeBody(w, r, &req, false) {
+		return
+	}
+	if req.Name != "" && req.Name != name {
+		sendJSONError(w, "Schedule name does not match the URL", http.StatusBadRequest)
+		return
+	}
+	req.Name = name
+
+	_, exists := s.ctrl.Config().GetSchedule(name)
+	s.putSchedule(w, req, !exists)
+}
+
+// putSchedule enregistre une programmation et la renvoie
+func (s *Server) putSchedule(w http.ResponseWriter, req ScheduleRequest, created bool) {
+	saved, err := s.saveSchedule(req)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendCreated(w, created, "schedules", saved.Name, s.scheduleInfo(saved))
+}
+
+// handleV1DeleteSchedule supprime une programmation
+func (s *Server) handleV1DeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	if err := s.ctrl.Config().RemoveSchedule(r.PathValue("name")); err != nil {
+		sendJSONError(w, err.Error(), errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,250 @@
+// Package audit tient le journal des commandes émises : par défaut un fichier
+// JSON lines en ajout seul, renouvelé au-delà d'une taille maximale.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Résultats d'une commande
+const (
+	ResultOK     = "ok"     // Commande émise
+	ResultError  = "error"  // Échec (télécommande inconnue, émetteur)
+	ResultDenied = "denied" // Refusée par une liste d'accès
+)
+
+// Valeurs par défaut des options
+const (
+	DefaultMaxSize    = 10 << 20 // Taille d'un fichier avant renouvellement (octets)
+	DefaultMaxFiles   = 5        // Anciens fichiers conservés (path.1 à path.N)
+	DefaultMaxEntries = 100000   // Entrées conservées par un historique en base
+)
+
+// History conserve les entrées du journal : fichier JSON lines (Log) ou base
+// de la configuration
+type History interface {
+	// Record ajoute une entrée ; une erreur n'interrompt pas l'émission
+	Record(entry Entry) error
+	// Query retourne les entrées du filtre, de la plus ancienne à la plus récente
+	Query(filter Filter) ([]Entry, error)
+	// Close libère le support
+	Close() error
+}
+
+// Entry est une ligne du journal
+type Entry struct {
+	Time        time.Time `json:"time"`
+	Remote      string    `json:"remote"`
+	Command     string    `json:"command"`
+	RollingCode *uint16   `json:"rolling_code,omitempty"` // Rolling code émis
+	Source      string    `json:"source"`                 // cli, http, mqtt ou schedule
+	Principal   string    `json:"principal,omitempty"`    // Jeton, programmation...
+	Address     string    `json:"address,omitempty"`      // Adresse réseau de l'appelant
+	Result      string    `json:"result"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// Options précise la rotation du journal
+type Options struct {
+	MaxSize    int64 // Taille maximale du fichier courant (octets)
+	MaxFiles   int   // Nombre d'anciens fichiers conservés
+	MaxEntries int   // Nombre d'entrées conservées par un historique en base
+}
+
+// Log est un journal d'audit ; un *Log nil n'enregistre rien
+type Log struct {
+	path string
+	opts Options
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// Open ouvre (ou crée) le journal path en ajout
+func Open(path string, opts Options) (*Log, error) {
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = DefaultMaxSize
+	}
+	if opts.MaxFiles <= 0 {
+		opts.MaxFiles = DefaultMaxFiles
+	}
+
+	l := &Log{path: path, opts: opts}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// open ouvre le fichier courant
+func (l *Log) open() error {
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	l.file, l.size = file, info.Size()
+	return nil
+}
+
+// Record ajoute une entrée au journal. Une erreur d'écriture n'interrompt pas
+// l'émission : elle est retournée pour être signalée par l'appelant.
+func (l *Log) Record(entry Entry) error {
+	if l == nil {
+		return nil
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.size > 0 && l.size+int64(len(line)) > l.opts.MaxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+	if err != nil {
+		return err
+	}
+	return l.file.Sync()
+}
+
+// rotate décale les anciens fichiers (path.1 devient path.2...) et en commence
+// un nouveau ; le plus ancien au-delà de MaxFiles est supprimé
+func (l *Log) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+
+	os.Remove(l.rotated(l.opts.MaxFiles))
+	for i := l.opts.MaxFiles - 1; i >= 1; i-- {
+		if err := os.Rename(l.rotated(i), l.rotated(i+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	if err := os.Rename(l.path, l.rotated(1)); err != nil {
+		return err
+	}
+	return l.open()
+}
+
+// rotated retourne le chemin du n-ième ancien fichier
+func (l *Log) rotated(n int) string {
+	return l.path + "." + strconv.Itoa(n)
+}
+
+// Close ferme le journal
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.file.Close()
+}
+
+// Filter sélectionne des entrées du journal ; les champs vides sont ignorés
+type Filter struct {
+	Remote    string
+	Source    string
+	Principal string
+	Since     time.Time
+	Until     time.Time
+	Limit     int // Nombre maximal d'entrées, les plus récentes
+
+	// Visible restreint les entrées aux télécommandes qu'il accepte ; les
+	// autres ne comptent pas dans Limit
+	Visible func(remote string) bool
+}
+
+// Match indique si une entrée satisfait le filtre
+func (f Filter) Match(entry Entry) bool {
+	switch {
+	case f.Remote != "" && entry.Remote != f.Remote,
+		f.Source != "" && entry.Source != f.Source,
+		f.Principal != "" && entry.Principal != f.Principal,
+		!f.Since.IsZero() && entry.Time.Before(f.Since),
+		!f.Until.IsZero() && !entry.Time.Before(f.Until),
+		f.Visible != nil && !f.Visible(entry.Remote):
+		return false
+	}
+	return true
+}
+
+// Query retourne les entrées correspondant au filtre, de la plus ancienne à la
+// plus récente, en parcourant aussi les fichiers renouvelés
+func (l *Log) Query(filter Filter) ([]Entry, error) {
+	if l == nil {
+		return nil, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := make([]Entry, 0)
+	for n := l.opts.MaxFiles; n >= 0; n-- {
+		path := l.path
+		if n > 0 {
+			path = l.rotated(n)
+		}
+		if err := scan(path, func(entry Entry) {
+			if !filter.Match(entry) {
+				return
+			}
+			entries = append(entries, entry)
+			if filter.Limit > 0 && len(entries) > filter.Limit {
+				entries = entries[1:]
+			}
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// scan lit les entrées d'un fichier ; un fichier absent est ignoré, une ligne
+// illisible (écriture interrompue) aussi
+func scan(path string, fn func(Entry)) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		fn(entry)
+	}
+	return scanner.Err()
+}
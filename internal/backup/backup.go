@@ -0,0 +1,223 @@
+// Package backup scelle la configuration dans une archive signée et
+// éventuellement chiffrée, et tient des sauvegardes locales à intervalle
+// régulier.
+//
+// L'archive est un document JSON. Sa signature HMAC-SHA256 couvre tous ses
+// champs ; la clé est celle du fichier de clé (backup.key), ou est dérivée de
+// la phrase secrète si l'archive est chiffrée (AES-256-GCM, clé PBKDF2) : une
+// archive chiffrée se restaure avec sa seule phrase secrète.
+package backup
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Identification du format d'archive
+const (
+	Format  = "rtscommander-backup"
+	version = 1
+)
+
+// Extension des fichiers d'archive
+const Extension = ".rtsbak"
+
+// Paramètres de dérivation de la phrase secrète. Une archive qui annonce un
+// nombre d'itérations hors de [iterations, maxIterations] est refusée avant
+// toute dérivation : elle pourrait affaiblir la clé ou bloquer la restauration.
+const (
+	iterations    = 600000
+	maxIterations = 10 * iterations
+	saltSize      = 16
+	keySize       = 32
+)
+
+// Erreurs d'ouverture d'une archive
+var (
+	ErrInvalidSignature   = errors.New("invalid backup signature")
+	ErrPassphraseRequired = errors.New("backup is encrypted, a passphrase is required")
+)
+
+// Archive est le document d'une sauvegarde
+type Archive struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	Created    time.Time `json:"created"`
+	Encrypted  bool      `json:"encrypted"`
+	Salt       []byte    `json:"salt,omitempty"`       // Sel PBKDF2 (archive chiffrée)
+	Iterations int       `json:"iterations,omitempty"` // Itérations PBKDF2
+	Nonce      []byte    `json:"nonce,omitempty"`      // Nonce AES-GCM
+	Payload    []byte    `json:"payload"`              // Configuration, chiffrée ou non
+	Signature  []byte    `json:"signature,omitempty"`
+}
+
+// Keys réunit les secrets d'une archive : la clé de signature d'une archive en
+// clair, ou la phrase secrète d'une archive chiffrée
+type Keys struct {
+	SigningKey []byte
+	Passphrase string
+}
+
+// Seal scelle la configuration payload ; elle est chiffrée si une phrase
+// secrète est donnée
+func Seal(payload []byte, keys Keys) ([]byte, error) {
+	a := &Archive{Format: Format, Version: version, Created: time.Now().UTC().Truncate(time.Second)}
+
+	macKey := keys.SigningKey
+	if keys.Passphrase != "" {
+		a.Encrypted = true
+		a.Iterations = iterations
+		a.Salt = make([]byte, saltSize)
+		if _, err := rand.Read(a.Salt); err != nil {
+			return nil, err
+		}
+
+		encKey, derivedMAC, err := derive(keys.Passphrase, a.Salt, a.Iterations)
+		if err != nil {
+			return nil, err
+		}
+		gcm, err := newGCM(encKey)
+		if err != nil {
+			return nil, err
+		}
+		a.Nonce = make([]byte, gcm.NonceSize())
+		if _, err := rand.Read(a.Nonce); err != nil {
+			return nil, err
+		}
+		payload = gcm.Seal(nil, a.Nonce, payload, []byte(Format))
+		macKey = derivedMAC
+	}
+	if len(macKey) == 0 {
+		return nil, fmt.Errorf("missing backup signing key")
+	}
+
+	a.Payload = payload
+	signature, err := sign(a, macKey)
+	if err != nil {
+		return nil, err
+	}
+	a.Signature = signature
+	return json.MarshalIndent(a, "", "  ")
+}
+
+// Open vérifie la signature d'une archive et retourne la configuration qu'elle
+// contient, déchiffrée si besoin
+func Open(data []byte, keys Keys) ([]byte, error) {
+	var a Archive
+	if err := json.Unmarshal(data, &a); err != nil || a.Format != Format {
+		return nil, fmt.Errorf("not an rtsCommander backup")
+	}
+	if a.Version > version {
+		return nil, fmt.Errorf("backup format version %d is newer than this program supports (%d)", a.Version, version)
+	}
+
+	macKey := keys.SigningKey
+	var encKey []byte
+	if a.Encrypted {
+		if keys.Passphrase == "" {
+			return nil, ErrPassphraseRequired
+		}
+		if a.Iterations < iterations || a.Iterations > maxIterations {
+			return nil, fmt.Errorf("backup key derivation uses %d iterations, outside the accepted range %d-%d", a.Iterations, iterations, maxIterations)
+		}
+		var err error
+		if encKey, macKey, err = derive(keys.Passphrase, a.Salt, a.Iterations); err != nil {
+			return nil, err
+		}
+	}
+	if len(macKey) == 0 {
+		return nil, fmt.Errorf("missing backup signing key")
+	}
+
+	signature := a.Signature
+	expected, err := sign(&a, macKey)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(signature, expected) {
+		if a.Encrypted {
+			return nil, fmt.Errorf("%w (wrong passphrase?)", ErrInvalidSignature)
+		}
+		return nil, fmt.Errorf("%w (wrong key, or modified backup)", ErrInvalidSignature)
+	}
+
+	if !a.Encrypted {
+		return a.Payload, nil
+	}
+	gcm, err := newGCM(encKey)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := gcm.Open(nil, a.Nonce, a.Payload, []byte(Format))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt backup: %v", err)
+	}
+	return payload, nil
+}
+
+// sign calcule la signature d'une archive, champ Signature exclu
+func sign(a *Archive, key []byte) ([]byte, error) {
+	unsigned := *a
+	unsigned.Signature = nil
+	data, err := json.Marshal(&unsigned)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil), nil
+}
+
+// derive dérive de la phrase secrète la clé de chiffrement et celle de signature
+func derive(passphrase string, salt []byte, iter int) (encKey, macKey []byte, err error) {
+	if iter < 1 || len(salt) == 0 {
+		return nil, nil, fmt.Errorf("invalid backup key derivation parameters")
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iter, 2*keySize)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key[:keySize], key[keySize:], nil
+}
+
+// newGCM prépare le chiffrement AES-256-GCM
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// LoadKey lit la clé de signature des archives en clair, ou la crée (32 octets
+// aléatoires, lisibles par le seul propriétaire) si elle n'existe pas
+func LoadKey(path string) ([]byte, error) {
+	key, err := os.ReadFile(path)
+	if err == nil {
+		if len(key) < keySize {
+			return nil, fmt.Errorf("backup key %s is too short", path)
+		}
+		return key, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	key = make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, key, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to create backup key: %v", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,178 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var (
+	testPayload = []byte(`{"schema_version": 2, "remotes": {"salon": {"address": 1193046, "rolling_code": 42}}}`)
+	testKey     = bytes.Repeat([]byte{0x5A}, keySize)
+	otherKey    = bytes.Repeat([]byte{0xA5}, keySize)
+)
+
+// sealed scelle testPayload
+func sealed(t *testing.T, keys Keys) []byte {
+	t.Helper()
+	data, err := Seal(testPayload, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// tamper modifie un champ de l'archive sans la signer à nouveau
+func tamper(t *testing.T, data []byte, modify func(a *Archive)) []byte {
+	t.Helper()
+	var a Archive
+	if err := json.Unmarshal(data, &a); err != nil {
+		t.Fatal(err)
+	}
+	modify(&a)
+	tampered, err := json.Marshal(&a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tampered
+}
+
+func TestSealOpenPlain(t *testing.T) {
+	data := sealed(t, Keys{SigningKey: testKey})
+
+	payload, err := Open(data, Keys{SigningKey: testKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(payload, testPayload) {
+		t.Errorf("payload = %s", payload)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		keys Keys
+		want error
+	}{
+		{"wrong key", data, Keys{SigningKey: otherKey}, ErrInvalidSignature},
+		{"missing key", data, Keys{}, nil},
+		{"tampered payload", tamper(t, data, func(a *Archive) {
+			a.Payload = bytes.Replace(a.Payload, []byte("42"), []byte("99"), 1)
+		}), Keys{SigningKey: testKey}, ErrInvalidSignature},
+		{"tampered date", tamper(t, data, func(a *Archive) {
+			a.Created = a.Created.Add(-1)
+		}), Keys{SigningKey: testKey}, ErrInvalidSignature},
+		{"signature removed", tamper(t, data, func(a *Archive) {
+			a.Signature = nil
+		}), Keys{SigningKey: testKey}, ErrInvalidSignature},
+		{"newer format", tamper(t, data, func(a *Archive) {
+			a.Version = version + 1
+		}), Keys{SigningKey: testKey}, nil},
+		{"not a backup", testPayload, Keys{SigningKey: testKey}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := Open(tt.data, tt.keys)
+			if err == nil {
+				t.Fatalf("opened: %s", payload)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSealRequiresKey(t *testing.T) {
+	if _, err := Seal(testPayload, Keys{}); err == nil {
+		t.Errorf("archive sealed without key")
+	}
+}
+
+func TestSealOpenEncrypted(t *testing.T) {
+	keys := Keys{Passphrase: "une phrase longue"}
+	data := sealed(t, keys)
+
+	if bytes.Contains(data, []byte("salon")) {
+		t.Errorf("encrypted archive contains the configuration in clear")
+	}
+
+	// La phrase secrète suffit, la clé de signature est ignorée
+	payload, err := Open(data, Keys{Passphrase: keys.Passphrase, SigningKey: otherKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(payload, testPayload) {
+		t.Errorf("payload = %s", payload)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		keys Keys
+		want error
+	}{
+		{"missing passphrase", data, Keys{SigningKey: testKey}, ErrPassphraseRequired},
+		{"wrong passphrase", data, Keys{Passphrase: "une autre phrase"}, ErrInvalidSignature},
+		{"tampered payload", tamper(t, data, func(a *Archive) {
+			a.Payload[0] ^= 0x01
+		}), keys, ErrInvalidSignature},
+		{"tampered nonce", tamper(t, data, func(a *Archive) {
+			a.Nonce[0] ^= 0x01
+		}), keys, ErrInvalidSignature},
+		{"decryption disabled", tamper(t, data, func(a *Archive) {
+			a.Encrypted = false
+		}), Keys{SigningKey: testKey}, ErrInvalidSignature},
+		{"too few iterations", tamper(t, data, func(a *Archive) {
+			a.Iterations = 1
+		}), keys, nil},
+		{"too many iterations", tamper(t, data, func(a *Archive) {
+			a.Iterations = maxIterations + 1
+		}), keys, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := Open(tt.data, tt.keys)
+			if err == nil {
+				t.Fatalf("opened: %s", payload)
+			}
+			// Sans erreur attendue : refusée avant la dérivation de la clé,
+			// donc avant la vérification de la signature
+			if tt.want == nil && errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("err = %v, want a refusal before key derivation", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backup.key")
+
+	// Créée au premier appel, relue ensuite
+	key, err := LoadKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key) != keySize {
+		t.Errorf("key size = %d", len(key))
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("key file mode = %v, %v", info.Mode(), err)
+	}
+	again, err := LoadKey(path)
+	if err != nil || !bytes.Equal(again, key) {
+		t.Errorf("key reloaded as %x, %v", again, err)
+	}
+
+	short := filepath.Join(t.TempDir(), "short.key")
+	os.WriteFile(short, []byte("secret"), 0o600)
+	if _, err := LoadKey(short); err == nil {
+		t.Errorf("short key accepted")
+	}
+}
@@ -0,0 +1,90 @@
+package backup
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Préfixe des sauvegardes locales, suivi de leur date
+const localPrefix = "rtscommander-"
+
+// Local enregistre des sauvegardes dans un répertoire et n'y conserve que les
+// plus récentes
+type Local struct {
+	Dir  string
+	Keep int // Nombre de sauvegardes conservées ; toutes si nul
+	Keys Keys
+}
+
+// Write scelle payload dans une nouvelle sauvegarde, puis supprime les plus
+// anciennes au-delà de Keep. Retourne le chemin de la sauvegarde.
+func (l *Local) Write(payload []byte) (string, error) {
+	data, err := Seal(payload, l.Keys)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(l.Dir, 0o700); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(l.Dir, FileName(time.Now()))
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", err
+	}
+	return path, l.prune()
+}
+
+// prune supprime les sauvegardes les plus anciennes au-delà de Keep ; leur nom
+// daté les trie dans l'ordre chronologique
+func (l *Local) prune() error {
+	if l.Keep <= 0 {
+		return nil
+	}
+	files, err := filepath.Glob(filepath.Join(l.Dir, localPrefix+"*"+Extension))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for len(files) > l.Keep {
+		if err := os.Remove(files[0]); err != nil {
+			return err
+		}
+		files = files[1:]
+	}
+	return nil
+}
+
+// Start enregistre une sauvegarde tout de suite, puis à chaque intervalle ;
+// export fournit la configuration à sauvegarder
+func (l *Local) Start(interval time.Duration, export func() ([]byte, error)) {
+	log.Printf("Backups every %s in %s (keeping %d)", interval, l.Dir, l.Keep)
+
+	go func() {
+		l.backup(export)
+		for range time.Tick(interval) {
+			l.backup(export)
+		}
+	}()
+}
+
+// backup enregistre une sauvegarde et journalise le résultat
+func (l *Local) backup(export func() ([]byte, error)) {
+	payload, err := export()
+	if err == nil {
+		var path string
+		if path, err = l.Write(payload); err == nil {
+			log.Printf("Backup saved to %s", path)
+			return
+		}
+	}
+	log.Printf("Warning: backup failed: %v", err)
+}
+
+// FileName retourne le nom d'une sauvegarde datée de t
+func FileName(t time.Time) string {
+	return localPrefix + t.Format("20060102-150405") + Extension
+}
@@ -0,0 +1,68 @@
+package backup
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLocalWritePrunesOldest(t *testing.T) {
+	l := &Local{Dir: t.TempDir(), Keep: 2, Keys: Keys{SigningKey: testKey}}
+
+	var old []string
+	for month := 1; month <= 3; month++ {
+		name := FileName(time.Date(2025, time.Month(month), 1, 3, 0, 0, 0, time.UTC))
+		os.WriteFile(filepath.Join(l.Dir, name), nil, 0o600)
+		old = append(old, name)
+	}
+	os.WriteFile(filepath.Join(l.Dir, "notes.txt"), nil, 0o600)
+
+	path, err := l.Write(testPayload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Seules les Keep plus récentes sont conservées ; les autres fichiers
+	// du répertoire sont ignorés
+	entries, err := os.ReadDir(l.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	want := []string{"notes.txt", old[2], filepath.Base(path)}
+	slices.Sort(want)
+	if !slices.Equal(names, want) {
+		t.Errorf("backup directory = %v, want %v", names, want)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := Open(data, l.Keys)
+	if err != nil || !bytes.Equal(payload, testPayload) {
+		t.Errorf("backup opened as %s, %v", payload, err)
+	}
+}
+
+func TestLocalWriteKeepsAll(t *testing.T) {
+	l := &Local{Dir: t.TempDir(), Keys: Keys{SigningKey: testKey}}
+	for month := 1; month <= 3; month++ {
+		name := FileName(time.Date(2025, time.Month(month), 1, 3, 0, 0, 0, time.UTC))
+		os.WriteFile(filepath.Join(l.Dir, name), nil, 0o600)
+	}
+	if _, err := l.Write(testPayload); err != nil {
+		t.Fatal(err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(l.Dir, "*"+Extension))
+	if len(files) != 4 {
+		t.Errorf("%d backups kept without Keep, want 4", len(files))
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrRollingCodeRegression signale une sauvegarde dont un rolling code est en
+// retard sur celui de la configuration : les moteurs ignoreraient ses commandes
+var ErrRollingCodeRegression = errors.New("rolling code regression")
+
+// Regression décrit un rolling code qui reculerait à la restauration
+type Regression struct {
+	Remote   string `json:"remote"`
+	Address  uint32 `json:"address"`
+	Current  uint16 `json:"current"`  // Rolling code de la configuration
+	Restored uint16 `json:"restored"` // Rolling code de la sauvegarde
+}
+
+// RestoreReport résume une restauration
+type RestoreReport struct {
+	SchemaVersion int          `json:"schema_version"` // Version du document restauré, avant migration
+	Remotes       int          `json:"remotes"`
+	Groups        int          `json:"groups"`
+	Schedules     int          `json:"schedules"`
+	Tokens        int          `json:"tokens"`
+	Regressions   []Regression `json:"regressions,omitempty"`
+}
+
+// Export retourne le document de configuration à sauvegarder : télécommandes
+// et rolling codes émis, groupes, programmations, site et jetons
+func (c *Config) Export() ([]byte, error) {
+	c.mu.RLock()
+	data, err := json.Marshal(c)
+	c.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// Blocs réservés et révision n'ont de sens que pour le support d'origine
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	delete(doc, "reserved")
+	delete(doc, "revision")
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// Restore remplace les télécommandes, groupes, programmations, site et jetons
+// par ceux du document data, migré si besoin. Une télécommande dont le rolling
+// code reculerait (même adresse, code en retard) fait refuser la restauration,
+// sauf avec force ; le rapport liste alors les régressions.
+func (c *Config) Restore(data []byte, force bool) (*RestoreReport, error) {
+	restored := newConfig(c.ConfigPath)
+	if err := parse(data, restored); err != nil {
+		return nil, fmt.Errorf("invalid backup: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	report := &RestoreReport{
+		SchemaVersion: SchemaVersion,
+		Remotes:       len(restored.Remotes),
+		Groups:        len(restored.Groups),
+		Schedules:     len(restored.Schedules),
+		Tokens:        len(restored.Tokens),
+		Regressions:   c.regressions(restored),
+	}
+	if m := restored.migration; m != nil {
+		report.SchemaVersion = m.From
+	}
+
+	if len(report.Regressions) > 0 && !force {
+		descriptions := make([]string, len(report.Regressions))
+		for i, r := range report.Regressions {
+			descriptions[i] = fmt.Sprintf("'%s' %d → %d", r.Remote, r.Current, r.Restored)
+		}
+		return report, fmt.Errorf("%w: %s (use force to restore anyway)", ErrRollingCodeRegression, strings.Join(descriptions, ", "))
+	}
+
+	c.Remotes = restored.Remotes
+	c.Groups = restored.Groups
+	c.Schedules = restored.Schedules
+	c.Location = restored.Location
+	c.Tokens = restored.Tokens
+	c.Reserved = make(map[string]uint16)
+
+	return report, c.save()
+}
+
+// regressions compare les rolling codes de la sauvegarde à ceux de la
+// configuration, par adresse : c'est elle qui est appairée avec les moteurs
+func (c *Config) regressions(restored *Config) []Regression {
+	current := make(map[uint32]uint16, len(c.Remotes))
+	for _, rc := range c.Remotes {
+		current[rc.Address] = rc.RollingCode
+	}
+
+	var regressions []Regression
+	for name, rc := range restored.Remotes {
+		code, exists := current[rc.Address]
+		if exists && ahead(code, rc.RollingCode) {
+			regressions = append(regressions, Regression{Remote: name, Address: rc.Address, Current: code, Restored: rc.RollingCode})
+		}
+	}
+	sort.Slice(regressions, func(i, j int) bool { return regressions[i].Remote < regressions[j].Remote })
+	return regressions
+}
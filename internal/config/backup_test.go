@@ -0,0 +1,135 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"rtscommander/m/internal/remote"
+)
+
+// openRemotes ouvre une configuration contenant les télécommandes données,
+// nom → {adresse, rolling code}
+func openRemotes(t *testing.T, remotes map[string][2]uint32) *Config {
+	t.Helper()
+	c, err := Load(filepath.Join(t.TempDir(), "remotes.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	for name, r := range remotes {
+		if err := c.AddRemote(name, &remote.Control{Address: r[0], RollingCode: uint16(r[1])}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return c
+}
+
+func TestExportRestoreRoundTrip(t *testing.T) {
+	source := openRemotes(t, map[string][2]uint32{"salon": {0x123456, 40}, "garage": {0xABCDEF, 7}})
+	if _, err := source.ReserveRollingCode("salon"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := source.Export()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Ni blocs réservés ni révision : propres au support d'origine
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"reserved", "revision"} {
+		if _, ok := doc[key]; ok {
+			t.Errorf("export contains %q", key)
+		}
+	}
+
+	target := openRemotes(t, nil)
+	report, err := target.Restore(data, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Remotes != 2 || report.SchemaVersion != SchemaVersion || len(report.Regressions) != 0 {
+		t.Errorf("report = %+v", *report)
+	}
+	// Le code émis est restauré, pas la borne du bloc
+	if got := target.Remotes["salon"].RollingCode; got != 41 {
+		t.Errorf("restored rolling code = %d, want 41", got)
+	}
+}
+
+func TestRestoreDetectsRegressions(t *testing.T) {
+	tests := []struct {
+		name        string
+		current     uint16
+		restored    uint16
+		regressions int
+	}{
+		{"same code", 40, 40, 0},
+		{"backup ahead", 40, 55, 0},
+		{"backup behind", 40, 12, 1},
+		{"current after wrap-around", 0x0003, 0xFFF0, 1},
+		{"backup after wrap-around", 0xFFF0, 0x0003, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := openRemotes(t, map[string][2]uint32{"salon": {0x123456, uint32(tt.current)}})
+			// Même adresse sous un autre nom : comparée quand même
+			backup := fmt.Sprintf(`{"schema_version": %d, "remotes": {"living": {"name": "living", "address": 1193046, "rolling_code": %d},
+				"new": {"name": "new", "address": 1, "rolling_code": 0}}}`, SchemaVersion, tt.restored)
+
+			report, err := c.Restore([]byte(backup), false)
+			if len(report.Regressions) != tt.regressions {
+				t.Fatalf("regressions = %+v, want %d", report.Regressions, tt.regressions)
+			}
+			if tt.regressions == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrRollingCodeRegression) {
+				t.Errorf("err = %v, want ErrRollingCodeRegression", err)
+			}
+			want := Regression{Remote: "living", Address: 0x123456, Current: tt.current, Restored: tt.restored}
+			if report.Regressions[0] != want {
+				t.Errorf("regression = %+v, want %+v", report.Regressions[0], want)
+			}
+			// Refusée : la configuration est inchangée
+			if _, exists := c.Remotes["living"]; exists || c.Remotes["salon"].RollingCode != tt.current {
+				t.Errorf("config modified by a refused restore: %v", c.ListRemotes())
+			}
+
+			// Forcée : restaurée malgré la régression
+			if _, err := c.Restore([]byte(backup), true); err != nil {
+				t.Fatal(err)
+			}
+			if rc, exists := c.Remotes["living"]; !exists || rc.RollingCode != tt.restored {
+				t.Errorf("forced restore: remotes %v", c.ListRemotes())
+			}
+		})
+	}
+}
+
+func TestRestoreMigratesBackup(t *testing.T) {
+	c := openRemotes(t, nil)
+	report, err := c.Restore([]byte(configV0), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.SchemaVersion != 0 || report.Remotes != 2 {
+		t.Errorf("report = %+v", *report)
+	}
+	if rc, exists := c.Remotes["salon"]; !exists || rc.RollingCode != 65535 {
+		t.Errorf("restored remotes = %v", c.ListRemotes())
+	}
+
+	if _, err := c.Restore([]byte(`{"schema_version": 99}`), true); !errors.Is(err, ErrNewerSchema) {
+		t.Errorf("newer backup: err = %v, want ErrNewerSchema", err)
+	}
+}
@@ -2,59 +2,114 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
-	"os"
+	"slices"
 	"sync"
 
 	"rtscommander/m/internal/remote"
 )
 
+// Erreurs permettant à l'appelant de distinguer la cause d'un échec
+var (
+	ErrNotFound = errors.New("not found")      // Élément inexistant
+	ErrExists   = errors.New("already exists") // Nom déjà utilisé
+	ErrInUse    = errors.New("in use")         // Élément référencé par un groupe ou une programmation
+)
+
 // Config représente la configuration de l'application
 type Config struct {
-	Remotes    map[string]*remote.Control `json:"remotes"`
-	ConfigPath string                     `json:"-"`
-	mu         sync.RWMutex               `json:"-"`
+	SchemaVersion int                        `json:"schema_version"` // Version du format (SchemaVersion)
+	Remotes       map[string]*remote.Control `json:"remotes"`
+	Groups        map[string]*Group          `json:"groups,omitempty"`
+	Schedules     map[string]*Schedule       `json:"schedules,omitempty"`
+	Location      *Location                  `json:"location,omitempty"`
+	Tokens        map[string]*Token          `json:"tokens,omitempty"`
+	Revision      uint64                     `json:"revision,omitempty"` // Incrémentée à chaque sauvegarde
+	Reserved      map[string]uint16          `json:"reserved,omitempty"` // Borne des blocs de rolling codes réservés
+	ConfigPath    string                     `json:"-"`
+
+	// Taille des blocs de rolling codes réservés (DefaultReserveBlock si nulle)
+	ReserveBlock int `json:"-"`
+
+	mu        sync.RWMutex
+	store     Store
+	migration *Migration // Migration effectuée au chargement
+	dirty     bool       // Modifications en mémoire non sauvegardées
+	stats     StorageStats
 }
 
-// Load charge la configuration depuis un fichier JSON
-func Load(path string) (*Config, error) {
-	config := &Config{
-		ConfigPath: path,
-		Remotes:    make(map[string]*remote.Control),
+// Taille par défaut des blocs de rolling codes réservés : un code sur 16
+// demande une écriture, et un arrêt brutal en saute au plus 15, ce que les
+// récepteurs Somfy tolèrent
+const DefaultReserveBlock = 16
+
+// newConfig crée une configuration vide
+func newConfig(path string) *Config {
+	return &Config{
+		SchemaVersion: SchemaVersion,
+		ConfigPath:    path,
+		Remotes:       make(map[string]*remote.Control),
+		Groups:        make(map[string]*Group),
+		Schedules:     make(map[string]*Schedule),
+		Tokens:        make(map[string]*Token),
+		Reserved:      make(map[string]uint16),
 	}
+}
 
-	data, err := os.ReadFile(path)
+// parse décode le document de configuration après l'avoir migré vers la
+// version courante du schéma ; la migration éventuelle est conservée dans
+// config.migration
+func parse(data []byte, config *Config) error {
+	migrated, m, err := migrate(data)
 	if err != nil {
-		if os.IsNotExist(err) {
-			log.Printf("Config file not found, creating new one: %s", path)
-			return config, nil
-		}
-		return nil, fmt.Errorf("failed to read config: %v", err)
+		return err
 	}
-
-	if err := json.Unmarshal(data, &config.Remotes); err != nil {
-		return nil, fmt.Errorf("failed to parse config: %v", err)
+	if m != nil {
+		data = migrated
+	}
+	if err := json.Unmarshal(data, config); err != nil {
+		return err
 	}
+	config.migration = m
 
-	log.Printf("Loaded %d remote(s) from %s", len(config.Remotes), path)
-	return config, nil
+	if config.Remotes == nil {
+		config.Remotes = make(map[string]*remote.Control)
+	}
+	if config.Groups == nil {
+		config.Groups = make(map[string]*Group)
+	}
+	if config.Schedules == nil {
+		config.Schedules = make(map[string]*Schedule)
+	}
+	if config.Tokens == nil {
+		config.Tokens = make(map[string]*Token)
+	}
+	if config.Reserved == nil {
+		config.Reserved = make(map[string]uint16)
+	}
+	return nil
 }
 
-// Save sauvegarde la configuration dans le fichier JSON
+// Save sauvegarde la configuration sur son support
 func (c *Config) Save() error {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	data, err := json.MarshalIndent(c.Remotes, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal config: %v", err)
-	}
+	return c.save()
+}
 
-	if err := os.WriteFile(c.ConfigPath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write config: %v", err)
+// save enregistre la configuration ; l'appelant doit détenir le verrou en
+// écriture
+func (c *Config) save() error {
+	c.Revision++
+	if err := c.store.Save(c); err != nil {
+		c.Revision--
+		return err
 	}
 
+	c.dirty = false
+	c.stats.Writes++
 	return nil
 }
 
@@ -65,8 +120,146 @@ func (c *Config) AddRemote(name string, rc *remote.Control) error {
 
 	rc.Name = name
 	c.Remotes[name] = rc
+	delete(c.Reserved, name)
 
-	return c.Save()
+	return c.save()
+}
+
+// RemoveRemote supprime une télécommande qui n'est utilisée par aucun groupe,
+// aucune programmation ni aucune liste d'accès de jeton
+func (c *Config) RemoveRemote(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.Remotes[name]; !exists {
+		return fmt.Errorf("remote '%s' %w", name, ErrNotFound)
+	}
+	for _, group := range c.Groups {
+		if group.Remote == name || slices.Contains(group.Members, name) {
+			return fmt.Errorf("remote '%s' %w by group '%s'", name, ErrInUse, group.Name)
+		}
+	}
+	for _, sc := range c.Schedules {
+		if sc.Remote == name {
+			return fmt.Errorf("remote '%s' %w by schedule '%s'", name, ErrInUse, sc.Name)
+		}
+	}
+	for _, token := range c.Tokens {
+		if slices.Contains(token.Remotes, name) {
+			return fmt.Errorf("remote '%s' %w by token '%s'", name, ErrInUse, token.Name)
+		}
+	}
+	delete(c.Remotes, name)
+	delete(c.Reserved, name)
+
+	return c.save()
+}
+
+// RenameRemote renomme une télécommande et met à jour les groupes, les
+// programmations et les listes d'accès des jetons qui la référencent
+func (c *Config) RenameRemote(name, newName string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.renameRemote(name, newName); err != nil {
+		return err
+	}
+	return c.save()
+}
+
+// UpdateRemote modifie une télécommande sous verrou puis sauvegarde la configuration
+func (c *Config) UpdateRemote(name string, update func(rc *remote.Control)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.updateRemote(name, update); err != nil {
+		return err
+	}
+	return c.save()
+}
+
+// PatchRemote renomme une télécommande si newName diffère de name, puis la
+// modifie, sous un même verrou et en une seule sauvegarde : en cas d'erreur,
+// rien n'est appliqué
+func (c *Config) PatchRemote(name, newName string, update func(rc *remote.Control)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.Remotes[name]; !exists {
+		return fmt.Errorf("remote '%s' %w", name, ErrNotFound)
+	}
+	if newName != name {
+		if err := c.renameRemote(name, newName); err != nil {
+			return err
+		}
+	}
+	if err := c.updateRemote(newName, update); err != nil {
+		return err
+	}
+	return c.save()
+}
+
+// renameRemote renomme une télécommande et ses références (groupes,
+// programmations, jetons), verrou tenu
+func (c *Config) renameRemote(name, newName string) error {
+	rc, exists := c.Remotes[name]
+	if !exists {
+		return fmt.Errorf("remote '%s' %w", name, ErrNotFound)
+	}
+	if newName == "" {
+		return fmt.Errorf("missing remote name")
+	}
+	if _, taken := c.Remotes[newName]; taken {
+		return fmt.Errorf("remote '%s' %w", newName, ErrExists)
+	}
+
+	delete(c.Remotes, name)
+	rc.Name = newName
+	c.Remotes[newName] = rc
+	if bound, reserved := c.Reserved[name]; reserved {
+		delete(c.Reserved, name)
+		c.Reserved[newName] = bound
+	}
+
+	for _, group := range c.Groups {
+		if group.Remote == name {
+			group.Remote = newName
+		}
+		for i, member := range group.Members {
+			if member == name {
+				group.Members[i] = newName
+			}
+		}
+	}
+	for _, sc := range c.Schedules {
+		if sc.Remote == name {
+			sc.Remote = newName
+		}
+	}
+	for _, token := range c.Tokens {
+		for i, allowed := range token.Remotes {
+			if allowed == name {
+				token.Remotes[i] = newName
+			}
+		}
+	}
+	return nil
+}
+
+// updateRemote modifie une télécommande, verrou tenu
+func (c *Config) updateRemote(name string, update func(rc *remote.Control)) error {
+	rc, exists := c.Remotes[name]
+	if !exists {
+		return fmt.Errorf("remote '%s' %w", name, ErrNotFound)
+	}
+	code := rc.RollingCode
+	update(rc)
+
+	// Rolling code fixé explicitement : le bloc réservé ne s'applique plus
+	if rc.RollingCode != code {
+		delete(c.Reserved, name)
+	}
+	return nil
 }
 
 // ListRemotes retourne la liste des noms de télécommandes
@@ -89,3 +282,15 @@ func (c *Config) GetRemote(name string) (*remote.Control, bool) {
 	rc, exists := c.Remotes[name]
 	return rc, exists
 }
+
+// Snapshot retourne une copie d'une télécommande, cohérente avec les mises à jour concurrentes
+func (c *Config) Snapshot(name string) (remote.Control, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	rc, exists := c.Remotes[name]
+	if !exists {
+		return remote.Control{}, false
+	}
+	return *rc, true
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"rtscommander/m/internal/remote"
+)
+
+func TestPatchRemote(t *testing.T) {
+	c := openTestConfig(t, filepath.Join(t.TempDir(), "remotes.json"), 40, 1)
+	defer c.Close()
+	if err := c.AddRemote("cuisine", &remote.Control{Address: 0xABCDEF}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AddGroup(Group{Name: "rdc", Members: []string{"salon", "cuisine"}}); err != nil {
+		t.Fatal(err)
+	}
+	setAddress := func(rc *remote.Control) { rc.Address = 0x654321 }
+
+	// Nom déjà pris : ni renommée ni modifiée
+	writes := c.Stats().Writes
+	if err := c.PatchRemote("salon", "cuisine", setAddress); !errors.Is(err, ErrExists) {
+		t.Errorf("err = %v, want ErrExists", err)
+	}
+	if rc := c.Remotes["salon"]; rc == nil || rc.Address != 0x123456 {
+		t.Errorf("salon after a refused patch = %+v", rc)
+	}
+	if err := c.PatchRemote("bureau", "bureau", setAddress); !errors.Is(err, ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+
+	// Renommée et modifiée en une seule écriture, références suivies
+	if err := c.PatchRemote("salon", "sejour", setAddress); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Stats().Writes - writes; got != 1 {
+		t.Errorf("%d writes, want 1", got)
+	}
+	if _, exists := c.Remotes["salon"]; exists {
+		t.Errorf("salon still present")
+	}
+	if rc := c.Remotes["sejour"]; rc == nil || rc.Name != "sejour" || rc.Address != 0x654321 || rc.RollingCode != 40 {
+		t.Errorf("sejour = %+v", rc)
+	}
+	if group, _ := c.GetGroup("rdc"); group.Members[0] != "sejour" {
+		t.Errorf("group members = %v", group.Members)
+	}
+
+	// Sans renommage : simple modification
+	if err := c.PatchRemote("cuisine", "cuisine", func(rc *remote.Control) { rc.TravelUpMs = 20000 }); err != nil {
+		t.Fatal(err)
+	}
+	if rc := c.Remotes["cuisine"]; rc.TravelUpMs != 20000 {
+		t.Errorf("cuisine = %+v", rc)
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Group représente un groupe nommé de télécommandes commandées ensemble.
+// Si Remote est renseigné, il désigne une télécommande de groupe dont l'adresse
+// est appairée avec le moteur de chaque membre : une seule émission les commande
+// tous simultanément.
+type Group struct {
+	Name    string   `json:"name"`
+	Members []string `json:"members"`
+	Remote  string   `json:"remote,omitempty"`
+}
+
+// AddGroup ajoute ou remplace un groupe ; tous ses membres doivent exister
+func (c *Config) AddGroup(group Group) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	name, members := group.Name, group.Members
+	if len(members) == 0 {
+		return fmt.Errorf("group '%s' has no members", name)
+	}
+	seen := make(map[string]bool, len(members))
+	for _, member := range members {
+		if _, exists := c.Remotes[member]; !exists {
+			return fmt.Errorf("remote '%s' not found", member)
+		}
+		if seen[member] {
+			return fmt.Errorf("remote '%s' listed twice in group '%s'", member, name)
+		}
+		seen[member] = true
+	}
+	if group.Remote != "" {
+		if _, exists := c.Remotes[group.Remote]; !exists {
+			return fmt.Errorf("group remote '%s' not found", group.Remote)
+		}
+		if seen[group.Remote] {
+			return fmt.Errorf("group remote '%s' cannot also be a member", group.Remote)
+		}
+	}
+
+	c.Groups[name] = &Group{
+		Name:    name,
+		Members: append([]string(nil), members...),
+		Remote:  group.Remote,
+	}
+
+	return c.save()
+}
+
+// RemoveGroup supprime un groupe qui n'est utilisé par aucune programmation
+func (c *Config) RemoveGroup(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.Groups[name]; !exists {
+		return fmt.Errorf("group '%s' %w", name, ErrNotFound)
+	}
+	for _, sc := range c.Schedules {
+		if sc.Group == name {
+			return fmt.Errorf("group '%s' %w by schedule '%s'", name, ErrInUse, sc.Name)
+		}
+	}
+	delete(c.Groups, name)
+
+	return c.save()
+}
+
+// ListGroups retourne la liste triée des noms de groupes
+func (c *Config) ListGroups() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	names := make([]string, 0, len(c.Groups))
+	for name := range c.Groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetGroup retourne une copie d'un groupe par son nom
+func (c *Config) GetGroup(name string) (Group, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	group, exists := c.Groups[name]
+	if !exists {
+		return Group{}, false
+	}
+	g := *group
+	g.Members = append([]string(nil), group.Members...)
+	return g, true
+}
+
+// MembersControlledBy retourne les membres des groupes dont remoteName est la
+// télécommande de groupe
+func (c *Config) MembersControlledBy(remoteName string) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var members []string
+	for _, group := range c.Groups {
+		if group.Remote == remoteName {
+			members = append(members, group.Members...)
+		}
+	}
+	return members
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"rtscommander/m/internal/audit"
+)
+
+// Historique des commandes : une entrée par clé séquentielle, de la plus
+// ancienne à la plus récente. Il ne fait pas partie du document de
+// configuration : ni migré, ni exporté dans les sauvegardes.
+var bucketHistory = []byte("history")
+
+// boltHistory enregistre le journal d'audit dans la base de la configuration ;
+// chaque entrée est une transaction synchronisée sur le disque
+type boltHistory struct {
+	db         *bolt.DB
+	maxEntries int
+}
+
+// History retourne le journal d'audit enregistré dans la base de la
+// configuration (support bolt uniquement). Une base sans historique reprend
+// le fichier JSON lines legacy et ses fichiers renouvelés, s'ils existent.
+// Au-delà de opts.MaxEntries, les entrées les plus anciennes sont supprimées.
+func (c *Config) History(legacy string, opts audit.Options) (audit.History, error) {
+	store, ok := c.store.(*boltStore)
+	if !ok {
+		return nil, fmt.Errorf("config store %s cannot hold the audit log", c.store)
+	}
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = audit.DefaultMaxEntries
+	}
+	h := &boltHistory{db: store.db, maxEntries: opts.MaxEntries}
+
+	empty := true
+	err := h.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(bucketHistory)
+		if err != nil {
+			return err
+		}
+		empty = bucket.Sequence() == 0
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit history: %v", err)
+	}
+	if empty && legacy != "" {
+		if err := h.importLegacy(legacy, opts); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// importLegacy reprend les entrées du journal JSON lines, laissé intact
+func (h *boltHistory) importLegacy(path string, opts audit.Options) error {
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+	legacy, err := audit.Open(path, opts)
+	if err != nil {
+		return err
+	}
+	defer legacy.Close()
+
+	entries, err := legacy.Query(audit.Filter{Limit: h.maxEntries})
+	if err != nil {
+		return fmt.Errorf("failed to read audit log %s: %v", path, err)
+	}
+	if err := h.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketHistory)
+		for _, entry := range entries {
+			if err := appendEntry(bucket, entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to import audit log: %v", err)
+	}
+	log.Printf("Imported %d audit entries from %s, the file is no longer used", len(entries), path)
+	return nil
+}
+
+// Record ajoute une entrée et supprime les plus anciennes au-delà de la limite
+func (h *boltHistory) Record(entry audit.Entry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	return h.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(bucketHistory)
+		if err != nil {
+			return err
+		}
+		if err := appendEntry(bucket, entry); err != nil {
+			return err
+		}
+
+		// Les clés se suivent : la plus ancienne conservée est last-max+1
+		last := bucket.Sequence()
+		if last <= uint64(h.maxEntries) {
+			return nil
+		}
+		oldest := sequenceKey(last - uint64(h.maxEntries) + 1)
+		var expired [][]byte
+		cursor := bucket.Cursor()
+		for key, _ := cursor.First(); key != nil && bytes.Compare(key, oldest) < 0; key, _ = cursor.Next() {
+			expired = append(expired, key)
+		}
+		for _, key := range expired {
+			if err := bucket.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Query parcourt l'historique de la plus récente à la plus ancienne entrée,
+// jusqu'à la limite du filtre
+func (h *boltHistory) Query(filter audit.Filter) ([]audit.Entry, error) {
+	entries := make([]audit.Entry, 0)
+	err := h.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketHistory)
+		if bucket == nil {
+			return nil
+		}
+		cursor := bucket.Cursor()
+		for key, value := cursor.Last(); key != nil; key, value = cursor.Prev() {
+			var entry audit.Entry
+			if err := json.Unmarshal(value, &entry); err != nil || !filter.Match(entry) {
+				continue
+			}
+			entries = append(entries, entry)
+			if filter.Limit > 0 && len(entries) == filter.Limit {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit history: %v", err)
+	}
+	slices.Reverse(entries)
+	return entries, nil
+}
+
+// Close n'a rien à libérer : la base est fermée avec la configuration
+func (h *boltHistory) Close() error {
+	return nil
+}
+
+// appendEntry enregistre une entrée sous la clé séquentielle suivante
+func appendEntry(bucket *bolt.Bucket, entry audit.Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	seq, err := bucket.NextSequence()
+	if err != nil {
+		return err
+	}
+	return bucket.Put(sequenceKey(seq), data)
+}
+
+// sequenceKey code un numéro de séquence en grand-boutiste : l'ordre des clés
+// est celui des entrées
+func sequenceKey(seq uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, seq)
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"rtscommander/m/internal/audit"
+)
+
+// openHistory ouvre une base de configuration et son historique
+func openHistory(t *testing.T, dir, legacy string, maxEntries int) (*Config, audit.History) {
+	t.Helper()
+	c, err := Open(StoreBolt, filepath.Join(dir, "remotes.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := c.History(legacy, audit.Options{MaxEntries: maxEntries})
+	if err != nil {
+		c.Close()
+		t.Fatal(err)
+	}
+	return c, h
+}
+
+// recordEntries ajoute count entrées, une par minute à partir de start
+func recordEntries(t *testing.T, h audit.History, start time.Time, count int) {
+	t.Helper()
+	for i := 0; i < count; i++ {
+		source := "http"
+		if i%2 == 1 {
+			source = "mqtt"
+		}
+		entry := audit.Entry{
+			Time:    start.Add(time.Duration(i) * time.Minute),
+			Remote:  fmt.Sprintf("remote%d", i),
+			Command: "up",
+			Source:  source,
+			Result:  audit.ResultOK,
+		}
+		if err := h.Record(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// remotesOf retourne les télécommandes des entrées, dans l'ordre
+func remotesOf(entries []audit.Entry) string {
+	var names string
+	for _, entry := range entries {
+		names += " " + entry.Remote
+	}
+	return names
+}
+
+func TestHistoryQuery(t *testing.T) {
+	start := time.Date(2026, 10, 17, 3, 0, 0, 0, time.UTC)
+	c, h := openHistory(t, t.TempDir(), "", 0)
+	defer c.Close()
+	recordEntries(t, h, start, 6)
+
+	tests := []struct {
+		name   string
+		filter audit.Filter
+		want   string
+	}{
+		{"all", audit.Filter{}, " remote0 remote1 remote2 remote3 remote4 remote5"},
+		{"most recent", audit.Filter{Limit: 2}, " remote4 remote5"},
+		{"source", audit.Filter{Source: "mqtt", Limit: 2}, " remote3 remote5"},
+		{"remote", audit.Filter{Remote: "remote2"}, " remote2"},
+		{"period", audit.Filter{Since: start.Add(time.Minute), Until: start.Add(3 * time.Minute)}, " remote1 remote2"},
+		{"none", audit.Filter{Principal: "token:absent"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := h.Query(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := remotesOf(entries); got != tt.want {
+				t.Errorf("Query = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistoryKeepsMaxEntries(t *testing.T) {
+	dir := t.TempDir()
+	c, h := openHistory(t, dir, "", 3)
+	recordEntries(t, h, time.Now(), 5)
+	c.Close()
+
+	// Conservé après réouverture, sans les entrées expirées
+	c, h = openHistory(t, dir, "", 3)
+	defer c.Close()
+	entries, err := h.Query(audit.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := remotesOf(entries), " remote2 remote3 remote4"; got != want {
+		t.Errorf("history = %q, want %q", got, want)
+	}
+}
+
+func TestHistoryImportsLegacyLog(t *testing.T) {
+	dir := t.TempDir()
+	legacyPath := filepath.Join(dir, "audit.jsonl")
+	legacy, err := audit.Open(legacyPath, audit.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recordEntries(t, legacy, time.Now(), 3)
+	legacy.Close()
+
+	c, h := openHistory(t, dir, legacyPath, 0)
+	recordEntries(t, h, time.Now(), 1)
+	c.Close()
+
+	// Importé une seule fois, à la première ouverture
+	c, h = openHistory(t, dir, legacyPath, 0)
+	defer c.Close()
+	entries, err := h.Query(audit.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := remotesOf(entries), " remote0 remote1 remote2 remote0"; got != want {
+		t.Errorf("history = %q, want %q", got, want)
+	}
+}
+
+func TestHistoryRequiresBoltStore(t *testing.T) {
+	c, err := Load(filepath.Join(t.TempDir(), "remotes.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err := c.History("", audit.Options{}); err == nil {
+		t.Errorf("history opened on a JSON store")
+	}
+}
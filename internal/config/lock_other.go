@@ -0,0 +1,16 @@
+//go:build !unix
+
+package config
+
+import (
+	"errors"
+	"os"
+)
+
+// errLocked signale un fichier de configuration ouvert par un autre processus
+var errLocked = errors.New("locked")
+
+// lockFile crée path sans le verrouiller : ces systèmes n'ont pas de flock
+func lockFile(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600)
+}
@@ -0,0 +1,29 @@
+//go:build unix
+
+package config
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// errLocked signale un fichier de configuration ouvert par un autre processus
+var errLocked = errors.New("locked")
+
+// lockFile prend un verrou exclusif sur path, créé au besoin ; le verrou est
+// libéré à la fermeture du fichier, y compris à l'arrêt brutal du processus
+func lockFile(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, errLocked
+		}
+		return nil, err
+	}
+	return file, nil
+}
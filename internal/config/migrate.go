@@ -0,0 +1,151 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// SchemaVersion est la version du format de configuration écrit par ce
+// programme ; elle doit suivre migrations
+const SchemaVersion = 2
+
+// ErrNewerSchema signale une configuration écrite par une version plus récente
+// du programme : elle n'est pas lue, une sauvegarde perdrait ses nouveaux champs
+var ErrNewerSchema = errors.New("newer schema version")
+
+// document est la configuration sous sa forme JSON générique, manipulée par
+// les migrations
+type document map[string]interface{}
+
+// migrations[i] fait passer le document de la version i à la version i+1 et
+// décrit chacun de ses changements
+var migrations = []struct {
+	description string
+	apply       func(doc document) []string
+}{
+	{"remote table moved into a configuration document", migrateDocument},
+	{"names aligned with their keys", migrateNames},
+}
+
+// Migration décrit la migration d'une configuration vers la version courante
+type Migration struct {
+	From    int
+	To      int
+	Changes []string
+}
+
+// migrate convertit un document de configuration vers SchemaVersion. Le
+// résultat est nil si le document est déjà à jour.
+func migrate(data []byte) ([]byte, *Migration, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // Adresses et rolling codes conservés à l'identique
+	var doc document
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, nil, err
+	}
+
+	version, err := schemaVersion(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	if version > SchemaVersion {
+		return nil, nil, fmt.Errorf("%w %d (this program supports %d), upgrade rtsCommander", ErrNewerSchema, version, SchemaVersion)
+	}
+	if version == SchemaVersion {
+		return nil, nil, nil
+	}
+
+	m := &Migration{From: version, To: SchemaVersion}
+	for v := version; v < SchemaVersion; v++ {
+		step := migrations[v]
+		m.Changes = append(m.Changes, fmt.Sprintf("v%d → v%d: %s", v, v+1, step.description))
+		for _, change := range step.apply(doc) {
+			m.Changes = append(m.Changes, "  "+change)
+		}
+		doc = withVersion(doc, v+1)
+	}
+
+	migrated, err := json.Marshal(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return migrated, m, nil
+}
+
+// schemaVersion retourne la version d'un document. Avant l'introduction de
+// schema_version, un document sans table "remotes" est une simple table des
+// télécommandes (version 0), sinon c'est la version 1.
+func schemaVersion(doc document) (int, error) {
+	if raw, ok := doc["schema_version"]; ok {
+		number, ok := raw.(json.Number)
+		version, err := number.Int64()
+		if !ok || err != nil || version < 1 {
+			return 0, fmt.Errorf("invalid schema_version %v", raw)
+		}
+		return int(version), nil
+	}
+
+	remotes, ok := doc["remotes"].(map[string]interface{})
+	if !ok {
+		return 0, nil
+	}
+	for _, rc := range remotes {
+		if _, ok := rc.(map[string]interface{}); !ok {
+			return 0, nil // Télécommande nommée "remotes" de l'ancien format
+		}
+	}
+	return 1, nil
+}
+
+// withVersion inscrit la version atteinte dans le document
+func withVersion(doc document, version int) document {
+	doc["schema_version"] = json.Number(fmt.Sprint(version))
+	return doc
+}
+
+// migrateDocument (v0 → v1) range l'ancienne table des télécommandes,
+// {"salon": {...}}, sous la clé "remotes" d'un document
+func migrateDocument(doc document) []string {
+	remotes := make(map[string]interface{}, len(doc))
+	for name, rc := range doc {
+		remotes[name] = rc
+		delete(doc, name)
+	}
+	doc["remotes"] = remotes
+	return []string{fmt.Sprintf("%d remote(s) moved under \"remotes\"", len(remotes))}
+}
+
+// migrateNames (v1 → v2) reporte la clé de chaque télécommande, groupe,
+// programmation et jeton dans son champ name, dont dépendent les renommages
+func migrateNames(doc document) []string {
+	var changes []string
+	for _, table := range []string{"remotes", "groups", "schedules", "tokens"} {
+		items, _ := doc[table].(map[string]interface{})
+		for _, key := range sortedKeys(items) {
+			item, ok := items[key].(map[string]interface{})
+			if !ok || item["name"] == key {
+				continue
+			}
+			if name, _ := item["name"].(string); name != "" {
+				changes = append(changes, fmt.Sprintf("%s '%s': name '%s' replaced by its key", table, key, name))
+			} else {
+				changes = append(changes, fmt.Sprintf("%s '%s': missing name set", table, key))
+			}
+			item["name"] = key
+		}
+	}
+	return changes
+}
+
+// sortedKeys retourne les clés d'une table dans l'ordre alphabétique
+func sortedKeys(items map[string]interface{}) []string {
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Configurations de chaque version du schéma, avec les mêmes télécommandes
+const (
+	configV0 = `{"salon": {"name": "Salon", "address": 1193046, "rolling_code": 65535, "encryption_key": 167},
+		"remotes": {"address": 11259375, "rolling_code": 12}}`
+	configV1 = `{"remotes": {"salon": {"name": "Salon", "address": 1193046, "rolling_code": 65535, "encryption_key": 167},
+		"remotes": {"address": 11259375, "rolling_code": 12}},
+		"groups": {"rdc": {"remotes": ["salon"]}}}`
+	configV2 = `{"schema_version": 2, "remotes": {"salon": {"name": "salon", "address": 1193046, "rolling_code": 65535}}}`
+)
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		from     int
+		changes  []string
+		hasGroup bool
+	}{
+		{"v0", configV0, 0, []string{
+			"v0 → v1: remote table moved into a configuration document",
+			"  2 remote(s) moved under \"remotes\"",
+			"v1 → v2: names aligned with their keys",
+			"  remotes 'remotes': missing name set",
+			"  remotes 'salon': name 'Salon' replaced by its key",
+		}, false},
+		{"v1", configV1, 1, []string{
+			"v1 → v2: names aligned with their keys",
+			"  remotes 'remotes': missing name set",
+			"  remotes 'salon': name 'Salon' replaced by its key",
+			"  groups 'rdc': missing name set",
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, m, err := migrate([]byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.From != tt.from || m.To != SchemaVersion {
+				t.Errorf("migration %d → %d, want %d → %d", m.From, m.To, tt.from, SchemaVersion)
+			}
+			if got, want := strings.Join(m.Changes, "\n"), strings.Join(tt.changes, "\n"); got != want {
+				t.Errorf("changes:\n%s\nwant:\n%s", got, want)
+			}
+
+			// Le document migré est lu sans nouvelle migration, codes intacts
+			config := newConfig("")
+			if err := parse(data, config); err != nil {
+				t.Fatal(err)
+			}
+			if config.migration != nil {
+				t.Errorf("migrated document migrated again: %+v", config.migration)
+			}
+			salon, other := config.Remotes["salon"], config.Remotes["remotes"]
+			if salon == nil || other == nil {
+				t.Fatalf("remotes = %v", config.ListRemotes())
+			}
+			if salon.Name != "salon" || salon.Address != 0x123456 || salon.RollingCode != 65535 || salon.EncryptionKey != 167 {
+				t.Errorf("salon = %+v", *salon)
+			}
+			if other.Name != "remotes" || other.Address != 0xABCDEF || other.RollingCode != 12 {
+				t.Errorf("remotes = %+v", *other)
+			}
+			if _, ok := config.Groups["rdc"]; ok != tt.hasGroup {
+				t.Errorf("group 'rdc' present: %v", ok)
+			}
+		})
+	}
+}
+
+func TestMigrateCurrentAndInvalid(t *testing.T) {
+	data, m, err := migrate([]byte(configV2))
+	if data != nil || m != nil || err != nil {
+		t.Errorf("current schema: got %s, %+v, %v", data, m, err)
+	}
+
+	if _, _, err := migrate([]byte(`{"schema_version": 3, "remotes": {}}`)); !errors.Is(err, ErrNewerSchema) {
+		t.Errorf("newer schema: err = %v, want ErrNewerSchema", err)
+	}
+	for _, invalid := range []string{`{"schema_version": 0}`, `{"schema_version": "2"}`, `[]`} {
+		if _, _, err := migrate([]byte(invalid)); err == nil || errors.Is(err, ErrNewerSchema) {
+			t.Errorf("%s: err = %v", invalid, err)
+		}
+	}
+}
+
+func TestOpenMigratesAndKeepsPreviousVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "remotes.json")
+	if err := os.WriteFile(path, []byte(configV0), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulation : rien n'est écrit
+	m, err := Migrate(StoreJSON, path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m == nil || m.From != 0 {
+		t.Fatalf("dry run migration = %+v", m)
+	}
+	assertContent(t, path, configV0)
+	if _, err := os.Stat(path + ".v0"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("dry run wrote %s.v0", filepath.Base(path))
+	}
+
+	// Migration : la version précédente est copiée telle quelle
+	if m, err = Migrate(StoreJSON, path, false); err != nil || m == nil {
+		t.Fatalf("migration = %+v, %v", m, err)
+	}
+	assertContent(t, path+".v0", configV0)
+
+	config, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.SchemaVersion != SchemaVersion || config.migration != nil {
+		t.Errorf("after migration: schema version %d, migration %+v", config.SchemaVersion, config.migration)
+	}
+	config.Close()
+	if m, err := Migrate(StoreJSON, path, true); m != nil || err != nil {
+		t.Errorf("second migration = %+v, %v", m, err)
+	}
+}
+
+func TestOpenRefusesNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "remotes.json")
+	newer := `{"schema_version": 99, "remotes": {}, "future": true}`
+	if err := os.WriteFile(path, []byte(newer), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(path); !errors.Is(err, ErrNewerSchema) {
+		t.Errorf("err = %v, want ErrNewerSchema", err)
+	}
+	// Le fichier n'est ni réécrit ni copié
+	assertContent(t, path, newer)
+	if _, err := os.Stat(path + bakSuffix); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("newer config rewritten")
+	}
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"rtscommander/m/internal/remote"
+)
+
+// skipReserved avance chaque rolling code jusqu'à la borne de son bloc réservé :
+// les codes du bloc ont pu être émis sans être sauvegardés. Retourne le nombre
+// de télécommandes avancées.
+func (c *Config) skipReserved() int {
+	skipped := 0
+	for name, bound := range c.Reserved {
+		rc, exists := c.Remotes[name]
+		if exists && ahead(bound, rc.RollingCode) {
+			log.Printf("[%s] Rolling code advanced from %d to %d", name, rc.RollingCode, bound)
+			rc.RollingCode = bound
+			skipped++
+		}
+	}
+	c.Reserved = make(map[string]uint16)
+	return skipped
+}
+
+// ahead indique si le rolling code a suit b, modulo 2^16 (le code reboucle
+// après 65535)
+func ahead(a, b uint16) bool {
+	return int16(a-b) > 0
+}
+
+// ReserveRollingCode retourne le rolling code à émettre pour une télécommande
+// et l'incrémente. Les codes sont réservés par blocs de ReserveBlock : la borne
+// du bloc est sauvegardée avant l'émission du premier code, les suivants ne
+// demandent aucune écriture. Après une coupure, Load repart de la borne.
+func (c *Config) ReserveRollingCode(name string) (uint16, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rc, exists := c.Remotes[name]
+	if !exists {
+		return 0, fmt.Errorf("remote '%s' %w", name, ErrNotFound)
+	}
+
+	code := rc.RollingCode
+	c.stats.RollingCodes++
+
+	if bound, reserved := c.Reserved[name]; reserved && ahead(bound, code) {
+		rc.RollingCode++
+		c.dirty = true
+		c.stats.WritesSaved++
+		return code, nil
+	}
+
+	// Nouveau bloc : sa borne est sur le disque avant toute émission
+	previous, hadBlock := c.Reserved[name]
+	c.Reserved[name] = code + uint16(c.reserveBlock())
+	rc.RollingCode++
+	if err := c.save(); err != nil {
+		rc.RollingCode = code
+		if hadBlock {
+			c.Reserved[name] = previous
+		} else {
+			delete(c.Reserved, name)
+		}
+		return 0, fmt.Errorf("failed to reserve rolling codes: %v", err)
+	}
+	c.stats.Reservations++
+	return code, nil
+}
+
+// reserveBlock retourne la taille des blocs de rolling codes
+func (c *Config) reserveBlock() int {
+	if c.ReserveBlock < 1 {
+		return DefaultReserveBlock
+	}
+	return c.ReserveBlock
+}
+
+// UpdateRemoteDeferred modifie une télécommande sans écrire sur le disque : la
+// modification est sauvegardée avec la prochaine écriture, par Flush ou par Close
+func (c *Config) UpdateRemoteDeferred(name string, update func(rc *remote.Control)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rc, exists := c.Remotes[name]
+	if !exists {
+		return fmt.Errorf("remote '%s' %w", name, ErrNotFound)
+	}
+	update(rc)
+
+	if c.dirty {
+		c.stats.WritesSaved++
+	}
+	c.dirty = true
+	return nil
+}
+
+// Flush sauvegarde les modifications différées, s'il y en a
+func (c *Config) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.dirty {
+		return nil
+	}
+	return c.save()
+}
+
+// AutoFlush sauvegarde les modifications différées à intervalle régulier
+func (c *Config) AutoFlush(interval time.Duration) {
+	go func() {
+		for range time.Tick(interval) {
+			if err := c.Flush(); err != nil {
+				log.Printf("Warning: failed to save config: %v", err)
+			}
+		}
+	}()
+}
+
+// inFlight indique si un bloc réservé contient encore des codes non émis :
+// sans nouvelle sauvegarde, le prochain démarrage les sauterait
+func (c *Config) inFlight() bool {
+	for name, bound := range c.Reserved {
+		if rc, exists := c.Remotes[name]; exists && ahead(bound, rc.RollingCode) {
+			return true
+		}
+	}
+	return false
+}
+
+// Close sauvegarde les rolling codes réellement émis et libère les blocs
+// réservés : le prochain démarrage ne sautera aucun code. Rien n'est écrit si
+// les codes émis sont déjà sur le disque (blocs d'un seul code). Le support
+// est ensuite fermé, la configuration ne doit plus être utilisée.
+func (c *Config) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.dirty || c.inFlight() {
+		c.Reserved = make(map[string]uint16)
+		if err := c.save(); err != nil {
+			c.store.Close()
+			return err
+		}
+	}
+	return c.store.Close()
+}
+
+// StorageStats compte les écritures de la configuration et celles évitées par
+// la réservation des rolling codes et les sauvegardes différées
+type StorageStats struct {
+	Store        string `json:"store"`         // Support de la configuration
+	Writes       uint64 `json:"writes"`        // Sauvegardes
+	RollingCodes uint64 `json:"rolling_codes"` // Rolling codes émis
+	Reservations uint64 `json:"reservations"`  // Blocs réservés (une écriture chacun)
+	WritesSaved  uint64 `json:"writes_saved"`  // Écritures évitées
+	ReserveBlock int    `json:"reserve_block"` // Taille des blocs
+}
+
+// Stats retourne les compteurs d'écriture depuis le chargement
+func (c *Config) Stats() StorageStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	stats := c.stats
+	stats.Store = c.store.String()
+	stats.ReserveBlock = c.reserveBlock()
+	return stats
+}
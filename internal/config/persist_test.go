@@ -0,0 +1,203 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"rtscommander/m/internal/remote"
+)
+
+func TestAhead(t *testing.T) {
+	tests := []struct {
+		a, b uint16
+		want bool
+	}{
+		{1, 0, true},
+		{0, 1, false},
+		{5, 5, false},
+		{16, 0, true},
+		{0x000A, 0xFFFA, true}, // Borne après le rebouclage
+		{0xFFFA, 0x000A, false},
+		{0x0000, 0xFFFF, true},
+		{0x7FFF, 0x0000, true},
+		{0x8000, 0x0000, false}, // Plus d'un demi-cycle : considéré en arrière
+	}
+	for _, tt := range tests {
+		if got := ahead(tt.a, tt.b); got != tt.want {
+			t.Errorf("ahead(0x%04X, 0x%04X) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSkipReserved(t *testing.T) {
+	tests := []struct {
+		name    string
+		rolling uint16
+		bound   uint16
+		want    uint16
+	}{
+		{"advanced to bound", 3, 16, 16},
+		{"already at bound", 16, 16, 16},
+		{"past bound", 20, 16, 20},
+		{"bound after wrap-around", 0xFFFA, 0x000A, 0x000A},
+		{"code after wrap-around", 0x0002, 0xFFFE, 0x0002},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newConfig("")
+			c.Remotes["salon"] = &remote.Control{RollingCode: tt.rolling}
+			c.Reserved["salon"] = tt.bound
+			c.Reserved["removed"] = 42
+
+			c.skipReserved()
+			if got := c.Remotes["salon"].RollingCode; got != tt.want {
+				t.Errorf("rolling code = 0x%04X, want 0x%04X", got, tt.want)
+			}
+			if len(c.Reserved) != 0 {
+				t.Errorf("reservations kept: %v", c.Reserved)
+			}
+		})
+	}
+}
+
+// openTestConfig ouvre une configuration JSON contenant "salon" avec le
+// rolling code donné
+func openTestConfig(t *testing.T, path string, rolling uint16, block int) *Config {
+	t.Helper()
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.ReserveBlock = block
+	if _, exists := c.Remotes["salon"]; !exists {
+		if err := c.AddRemote("salon", &remote.Control{Address: 0x123456, RollingCode: rolling}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return c
+}
+
+// sendCodes réserve count rolling codes et vérifie qu'ils se suivent
+func sendCodes(t *testing.T, c *Config, first uint16, count int) {
+	t.Helper()
+	for i := 0; i < count; i++ {
+		code, err := c.ReserveRollingCode("salon")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := first + uint16(i); code != want {
+			t.Fatalf("code %d = 0x%04X, want 0x%04X", i, code, want)
+		}
+	}
+}
+
+// storedCode relit le rolling code de "salon" sur le disque
+func storedCode(t *testing.T, path string) uint16 {
+	t.Helper()
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	return c.Remotes["salon"].RollingCode
+}
+
+func TestReserveRollingCodeWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "remotes.json")
+	c := openTestConfig(t, path, 100, 4)
+	before := c.Stats().Writes
+
+	// Une écriture par bloc de 4 codes
+	sendCodes(t, c, 100, 10)
+	stats := c.Stats()
+	if got := stats.Writes - before; got != 3 {
+		t.Errorf("%d writes for 10 codes, want 3", got)
+	}
+	if stats.Reservations != 3 || stats.WritesSaved != 7 || stats.RollingCodes != 10 {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	// Fermeture propre : les codes émis sont sauvegardés, rien n'est sauté
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := storedCode(t, path); got != 110 {
+		t.Errorf("stored rolling code after Close = %d, want 110", got)
+	}
+}
+
+func TestReserveRollingCodeAfterCrash(t *testing.T) {
+	tests := []struct {
+		name  string
+		first uint16
+		sent  int
+		want  uint16
+	}{
+		{"within block", 100, 3, 116},
+		{"second block", 100, 17, 132},
+		{"wrap-around", 0xFFFA, 3, 0x000A},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "remotes.json")
+			c := openTestConfig(t, path, tt.first, 16)
+			sendCodes(t, c, tt.first, tt.sent)
+
+			// Arrêt brutal : pas de Close, seul le verrou est libéré ; le
+			// redémarrage repart de la borne
+			c.store.Close()
+			if got := storedCode(t, path); got != tt.want {
+				t.Errorf("rolling code after restart = 0x%04X, want 0x%04X", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCloseSkipsWriteWithoutCodesInFlight(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "remotes.json")
+	c := openTestConfig(t, path, 0xFFFF, 1)
+	before := c.Stats().Writes
+
+	// Commande unique : une seule écriture, avant l'émission
+	sendCodes(t, c, 0xFFFF, 1)
+	if got := c.Stats().Writes - before; got != 1 {
+		t.Errorf("%d writes for one code, want 1", got)
+	}
+	revision := c.Revision
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, _, err := (&jsonStore{path: path}).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.Revision != revision {
+		t.Errorf("Close wrote the config again: revision %d, want %d", reloaded.Revision, revision)
+	}
+	if got := storedCode(t, path); got != 0 {
+		t.Errorf("stored rolling code = 0x%04X, want 0x0000", got)
+	}
+}
+
+func TestCloseSavesDeferredUpdates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "remotes.json")
+	c := openTestConfig(t, path, 7, 1)
+
+	position := 40
+	if err := c.UpdateRemoteDeferred("salon", func(rc *remote.Control) { rc.Position = &position }); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reloaded.Close()
+	if p := reloaded.Remotes["salon"].Position; p == nil || *p != 40 {
+		t.Errorf("position after Close = %v, want 40", p)
+	}
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Location regroupe les réglages liés au lieu d'installation
+type Location struct {
+	Timezone  string   `json:"timezone,omitempty"`  // Fuseau IANA, ex. "Europe/Paris" (heure locale du système par défaut)
+	Latitude  *float64 `json:"latitude,omitempty"`  // Degrés, nord positif
+	Longitude *float64 `json:"longitude,omitempty"` // Degrés, est positif
+}
+
+// Schedule représente une commande programmée, exécutée à heure fixe ou
+// relative au soleil les jours indiqués
+type Schedule struct {
+	Name     string     `json:"name"`
+	Days     []string   `json:"days,omitempty"`     // mon..sun, "weekdays", "weekend" ; vide = tous les jours
+	Time     string     `json:"time"`               // Heure locale "HH:MM", ou "sunrise"/"sunset" et un décalage ("sunset+15min")
+	Timezone string     `json:"timezone,omitempty"` // Remplace le fuseau de la configuration
+	Remote   string     `json:"remote,omitempty"`
+	Group    string     `json:"group,omitempty"`
+	Command  string     `json:"command"`
+	Value    *int       `json:"value,omitempty"` // Position cible (commande "position")
+	Disabled bool       `json:"disabled,omitempty"`
+	LastRun  *time.Time `json:"last_run,omitempty"` // Dernière occurrence exécutée (ou ignorée)
+}
+
+// Timezone retourne le nom du fuseau horaire configuré ("" = heure locale du système)
+func (c *Config) Timezone() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.Location == nil {
+		return ""
+	}
+	return c.Location.Timezone
+}
+
+// SetTimezone enregistre le fuseau horaire de l'installation
+func (c *Config) SetTimezone(tz string) error {
+	if _, err := time.LoadLocation(tz); err != nil {
+		return fmt.Errorf("invalid timezone '%s': %v", tz, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.Location == nil {
+		c.Location = &Location{}
+	}
+	c.Location.Timezone = tz
+
+	return c.save()
+}
+
+// Coordinates retourne la latitude et la longitude de l'installation, si connues
+func (c *Config) Coordinates() (float64, float64, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.Location == nil || c.Location.Latitude == nil || c.Location.Longitude == nil {
+		return 0, 0, false
+	}
+	return *c.Location.Latitude, *c.Location.Longitude, true
+}
+
+// SetCoordinates enregistre la latitude et la longitude de l'installation
+func (c *Config) SetCoordinates(latitude, longitude float64) error {
+	if latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180 {
+		return fmt.Errorf("invalid coordinates %g, %g", latitude, longitude)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.Location == nil {
+		c.Location = &Location{}
+	}
+	c.Location.Latitude = &latitude
+	c.Location.Longitude = &longitude
+
+	return c.save()
+}
+
+// AddSchedule ajoute ou remplace une programmation. Les occurrences antérieures
+// à l'ajout ne sont pas rattrapées.
+func (c *Config) AddSchedule(sc Schedule) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if sc.Name == "" {
+		return fmt.Errorf("missing schedule name")
+	}
+	if (sc.Remote == "") == (sc.Group == "") {
+		return fmt.Errorf("schedule '%s' needs either a remote or a group", sc.Name)
+	}
+	if sc.Remote != "" {
+		if _, exists := c.Remotes[sc.Remote]; !exists {
+			return fmt.Errorf("remote '%s' not found", sc.Remote)
+		}
+	}
+	if sc.Group != "" {
+		if _, exists := c.Groups[sc.Group]; !exists {
+			return fmt.Errorf("group '%s' not found", sc.Group)
+		}
+	}
+	if sc.Timezone != "" {
+		if _, err := time.LoadLocation(sc.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone '%s': %v", sc.Timezone, err)
+		}
+	}
+
+	if sc.LastRun == nil {
+		now := time.Now()
+		sc.LastRun = &now
+	}
+	sc.Days = append([]string(nil), sc.Days...)
+	c.Schedules[sc.Name] = &sc
+
+	return c.save()
+}
+
+// RemoveSchedule supprime une programmation
+func (c *Config) RemoveSchedule(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.Schedules[name]; !exists {
+		return fmt.Errorf("schedule '%s' %w", name, ErrNotFound)
+	}
+	delete(c.Schedules, name)
+
+	return c.save()
+}
+
+// ListSchedules retourne la liste triée des noms de programmations
+func (c *Config) ListSchedules() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	names := make([]string, 0, len(c.Schedules))
+	for name := range c.Schedules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetSchedule retourne une copie d'une programmation par son nom
+func (c *Config) GetSchedule(name string) (Schedule, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	sc, exists := c.Schedules[name]
+	if !exists {
+		return Schedule{}, false
+	}
+	copied := *sc
+	copied.Days = append([]string(nil), sc.Days...)
+	return copied, true
+}
+
+// MarkScheduleRun enregistre l'occurrence traitée d'une programmation, pour ne
+// pas l'exécuter une seconde fois après un redémarrage
+func (c *Config) MarkScheduleRun(name string, occurrence time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sc, exists := c.Schedules[name]
+	if !exists {
+		return fmt.Errorf("schedule '%s' %w", name, ErrNotFound)
+	}
+	sc.LastRun = &occurrence
+
+	return c.save()
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"log"
+)
+
+// Store conserve la configuration sur un support persistant. Save enregistre
+// la configuration complète en une seule transaction : une coupure laisse
+// l'ancienne ou la nouvelle version, jamais un mélange des deux.
+type Store interface {
+	// Load lit la configuration enregistrée, nil si le support est vide.
+	// recovered signale une version de secours, à réécrire aussitôt.
+	Load() (config *Config, recovered bool, err error)
+	// Save enregistre la configuration ; l'appelant détient son verrou
+	Save(c *Config) error
+	// Backup copie le support tel qu'il a été lu, avant toute sauvegarde
+	Backup(path string) error
+	// Close libère le support
+	Close() error
+	// String décrit le support dans les journaux
+	String() string
+}
+
+// Supports de configuration disponibles
+const (
+	StoreJSON = "json" // Document JSON (remotes.json), par défaut
+	StoreBolt = "bolt" // Base bbolt embarquée (remotes.db)
+)
+
+// OpenStore ouvre le support kind situé à path
+func OpenStore(kind, path string) (Store, error) {
+	switch kind {
+	case StoreJSON, "":
+		return openJSONStore(path)
+	case StoreBolt:
+		return openBoltStore(path)
+	}
+	return nil, fmt.Errorf("unknown store '%s' (expected %s or %s)", kind, StoreJSON, StoreBolt)
+}
+
+// Load charge la configuration depuis un fichier JSON
+func Load(path string) (*Config, error) {
+	return Open(StoreJSON, path)
+}
+
+// Open charge la configuration depuis le support kind. Après une coupure de
+// courant, la version valide la plus récente est retenue et les rolling codes
+// réservés avant l'interruption sont sautés.
+func Open(kind, path string) (*Config, error) {
+	store, err := OpenStore(kind, path)
+	if err != nil {
+		return nil, err
+	}
+
+	config, repaired, err := store.Load()
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+	if config == nil {
+		log.Printf("Config not found, creating new one: %s", store)
+		config = newConfig(path)
+		config.store = store
+		return config, nil
+	}
+	config.ConfigPath = path
+	config.store = store
+
+	if m := config.migration; m != nil {
+		backup := fmt.Sprintf("%s.v%d", path, m.From)
+		if err := store.Backup(backup); err != nil {
+			store.Close()
+			return nil, fmt.Errorf("failed to back up config before migration: %v", err)
+		}
+		log.Printf("Config migrated from schema version %d to %d, previous version kept in %s", m.From, m.To, backup)
+		for _, change := range m.Changes {
+			log.Printf("  %s", change)
+		}
+		repaired = true
+	}
+	if skipped := config.skipReserved(); skipped > 0 {
+		log.Printf("Unclean shutdown: rolling code of %d remote(s) advanced to the reserved bound", skipped)
+		repaired = true
+	}
+	if repaired {
+		if err := config.Save(); err != nil {
+			store.Close()
+			return nil, fmt.Errorf("failed to save recovered config: %v", err)
+		}
+	}
+
+	log.Printf("Loaded %d remote(s) and %d group(s) from %s", len(config.Remotes), len(config.Groups), store)
+	return config, nil
+}
+
+// Migrate migre la configuration vers la version courante du schéma et
+// retourne les changements, nil si elle est déjà à jour. Avec dryRun, rien
+// n'est écrit.
+func Migrate(kind, path string, dryRun bool) (*Migration, error) {
+	if !dryRun {
+		config, err := Open(kind, path)
+		if err != nil {
+			return nil, err
+		}
+		return config.migration, config.Close()
+	}
+
+	store, err := OpenStore(kind, path)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	config, _, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, fmt.Errorf("config %s %w", store, ErrNotFound)
+	}
+	return config.migration, nil
+}
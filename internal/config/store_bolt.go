@@ -0,0 +1,212 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Compartiments de la base, nommés comme les tables du document JSON : un
+// enregistrement par élément, indexé par son nom, pour que chaque sauvegarde
+// ne réécrive que les éléments modifiés
+var (
+	bucketMeta      = []byte("meta") // Valeurs simples du document (schema_version, revision, location)
+	bucketRemotes   = []byte("remotes")
+	bucketGroups    = []byte("groups")
+	bucketSchedules = []byte("schedules")
+	bucketTokens    = []byte("tokens")
+	bucketReserved  = []byte("reserved")
+
+	bucketTables = [][]byte{bucketRemotes, bucketGroups, bucketSchedules, bucketTokens, bucketReserved}
+)
+
+// Clés du compartiment meta
+var (
+	keySchemaVersion = []byte("schema_version")
+	keyRevision      = []byte("revision")
+	keyLocation      = []byte("location")
+)
+
+// boltStore enregistre la configuration dans une base bbolt : chaque
+// sauvegarde est une transaction synchronisée sur le disque
+type boltStore struct {
+	path string
+	db   *bolt.DB
+}
+
+// openBoltStore ouvre (ou crée) la base. Elle est verrouillée par le processus
+// qui l'ouvre : un second processus échoue au lieu d'écraser les rolling codes.
+func openBoltStore(path string) (*boltStore, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("%s is locked by another rtsCommander process (send commands through it with --server)", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config database: %v", err)
+	}
+	return &boltStore{path: path, db: db}, nil
+}
+
+// Load lit la configuration de la base. Les enregistrements sont rassemblés en
+// un document JSON, migré comme le fichier. Une base vide reprend le fichier
+// JSON du même nom (remotes.json pour remotes.db), s'il existe.
+func (s *boltStore) Load() (*Config, bool, error) {
+	doc := make(map[string]json.RawMessage)
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(bucketMeta)
+		if meta == nil {
+			return nil
+		}
+		meta.ForEach(func(key, value []byte) error {
+			doc[string(key)] = bytes.Clone(value)
+			return nil
+		})
+
+		for _, name := range bucketTables {
+			table := make(map[string]json.RawMessage)
+			if bucket := tx.Bucket(name); bucket != nil {
+				bucket.ForEach(func(key, value []byte) error {
+					table[string(key)] = bytes.Clone(value)
+					return nil
+				})
+			}
+			data, err := json.Marshal(table)
+			if err != nil {
+				return err
+			}
+			doc[string(name)] = data
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read config database %s: %v", s.path, err)
+	}
+
+	if len(doc) > 0 {
+		data, err := json.Marshal(doc)
+		if err != nil {
+			return nil, false, err
+		}
+		config := newConfig(s.path)
+		if err := parse(data, config); err != nil {
+			return nil, false, fmt.Errorf("invalid config database %s: %v", s.path, err)
+		}
+		return config, false, nil
+	}
+
+	// Passage du fichier JSON à la base : les rolling codes sont conservés
+	legacy := strings.TrimSuffix(s.path, filepath.Ext(s.path)) + ".json"
+	if _, err := os.Stat(legacy); err != nil {
+		return nil, false, nil
+	}
+	config, _, err := (&jsonStore{path: legacy}).Load()
+	if err != nil || config == nil {
+		return nil, false, err
+	}
+	log.Printf("Importing %s into %s, the JSON file is no longer used", legacy, s.path)
+	config.migration = nil // Le fichier JSON reste intact, sans copie à faire
+	return config, true, nil
+}
+
+// Save enregistre la configuration en une transaction ; seuls les éléments
+// modifiés, ajoutés ou supprimés sont écrits
+func (s *boltStore) Save(c *Config) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		meta, err := tx.CreateBucketIfNotExists(bucketMeta)
+		if err != nil {
+			return err
+		}
+		if err := put(meta, keySchemaVersion, c.SchemaVersion); err != nil {
+			return err
+		}
+		if err := put(meta, keyRevision, c.Revision); err != nil {
+			return err
+		}
+		if c.Location != nil {
+			err = put(meta, keyLocation, c.Location)
+		} else {
+			err = meta.Delete(keyLocation)
+		}
+		if err != nil {
+			return err
+		}
+
+		return errors.Join(
+			putAll(tx, bucketRemotes, c.Remotes),
+			putAll(tx, bucketGroups, c.Groups),
+			putAll(tx, bucketSchedules, c.Schedules),
+			putAll(tx, bucketTokens, c.Tokens),
+			putAll(tx, bucketReserved, c.Reserved),
+		)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write config database: %v", err)
+	}
+	return nil
+}
+
+// Backup copie la base dans une transaction de lecture : la copie est cohérente
+func (s *boltStore) Backup(path string) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return tx.CopyFile(path, 0o600)
+	})
+}
+
+// Close ferme la base et libère son verrou
+func (s *boltStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *boltStore) String() string {
+	return "bolt:" + s.path
+}
+
+// putAll aligne un compartiment sur items : les éléments modifiés sont
+// réécrits, les éléments disparus supprimés
+func putAll[T any](tx *bolt.Tx, name []byte, items map[string]T) error {
+	bucket, err := tx.CreateBucketIfNotExists(name)
+	if err != nil {
+		return err
+	}
+
+	var removed [][]byte
+	bucket.ForEach(func(key, _ []byte) error {
+		if _, exists := items[string(key)]; !exists {
+			removed = append(removed, key)
+		}
+		return nil
+	})
+	for _, key := range removed {
+		if err := bucket.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	for key, item := range items {
+		if err := put(bucket, []byte(key), item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// put enregistre une valeur si elle a changé
+func put(bucket *bolt.Bucket, key []byte, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(bucket.Get(key), data) {
+		return nil
+	}
+	return bucket.Put(key, data)
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+
+	"rtscommander/m/internal/remote"
+)
+
+// bucketKeys retourne les clés d'un compartiment de la base
+func bucketKeys(t *testing.T, store *boltStore, name []byte) []string {
+	t.Helper()
+	var keys []string
+	err := store.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(name).ForEach(func(key, _ []byte) error {
+			keys = append(keys, string(key))
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func TestBoltStorePutAll(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "remotes.db")
+	c, err := Open(StoreBolt, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := c.store.(*boltStore)
+	for _, name := range []string{"salon", "cuisine", "garage"} {
+		if err := c.AddRemote(name, &remote.Control{Address: 0x123456, RollingCode: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := c.UpdateRemote("salon", func(rc *remote.Control) { rc.RollingCode = 50 }); err != nil {
+		t.Fatal(err)
+	}
+
+	// Suppression et renommage alignent le compartiment
+	if err := c.RemoveRemote("garage"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.RenameRemote("cuisine", "kitchen"); err != nil {
+		t.Fatal(err)
+	}
+	keys := bucketKeys(t, store, bucketRemotes)
+	if want := []string{"kitchen", "salon"}; !slices.Equal(keys, want) {
+		t.Errorf("remotes bucket = %v, want %v", keys, want)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := Open(StoreBolt, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reloaded.Close()
+	if got := reloaded.ListRemotes(); len(got) != 2 || reloaded.Remotes["salon"].RollingCode != 50 {
+		t.Errorf("reloaded remotes %v, salon rolling code %d", got, reloaded.Remotes["salon"].RollingCode)
+	}
+}
+
+func TestBoltStoreImportsLegacyJSON(t *testing.T) {
+	dir := t.TempDir()
+	legacy := filepath.Join(dir, "remotes.json")
+	if err := os.WriteFile(legacy, []byte(revisionDoc(7, 321)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Open(StoreBolt, filepath.Join(dir, "remotes.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Remotes["salon"].RollingCode; got != 321 {
+		t.Errorf("imported rolling code = %d, want 321", got)
+	}
+	// Import enregistré aussitôt, sans copie de migration
+	keys := bucketKeys(t, c.store.(*boltStore), bucketRemotes)
+	if !slices.Equal(keys, []string{"salon"}) {
+		t.Errorf("remotes bucket after import = %v", keys)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "remotes.db.v0")); err == nil {
+		t.Errorf("migration copy written for an import")
+	}
+	if err := c.UpdateRemote("salon", func(rc *remote.Control) { rc.RollingCode = 400 }); err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+
+	// Le fichier JSON reste intact et n'est plus lu
+	assertContent(t, legacy, revisionDoc(7, 321))
+	reloaded, err := Open(StoreBolt, filepath.Join(dir, "remotes.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reloaded.Close()
+	if got := reloaded.Remotes["salon"].RollingCode; got != 400 {
+		t.Errorf("rolling code after reopening = %d, want 400", got)
+	}
+}
+
+func TestBoltStoreLocked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "remotes.db")
+	c, err := Open(StoreBolt, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if _, err := Open(StoreBolt, path); err == nil {
+		t.Errorf("database opened twice")
+	}
+}
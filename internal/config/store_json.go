@@ -0,0 +1,189 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// Fichiers annexes de la configuration JSON, à côté du fichier principal
+const (
+	tmpSuffix  = ".tmp"  // Nouvelle version en cours d'écriture
+	bakSuffix  = ".bak"  // Version précédente
+	lockSuffix = ".lock" // Verrou du processus qui utilise la configuration
+)
+
+// rename remplace un fichier par un autre ; remplacé dans les tests pour
+// simuler un fichier monté seul
+var rename = os.Rename
+
+// jsonStore enregistre la configuration dans un document JSON, réécrit en
+// entier à chaque sauvegarde
+type jsonStore struct {
+	path   string
+	lock   *os.File // Verrou détenu jusqu'à Close
+	loaded []byte   // Document lu par Load, copié par Backup
+}
+
+// openJSONStore verrouille la configuration path. Comme la base bolt, elle
+// n'est utilisée que par un processus : une CLI qui la modifierait pendant que
+// le serveur tourne serait écrasée par sa sauvegarde suivante.
+func openJSONStore(path string) (*jsonStore, error) {
+	lock, err := lockFile(path + lockSuffix)
+	if errors.Is(err, errLocked) {
+		return nil, fmt.Errorf("%s is locked by another rtsCommander process (send commands through it with --server)", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock config: %v", err)
+	}
+	return &jsonStore{path: path, lock: lock}, nil
+}
+
+// Load lit la version valide la plus récente parmi le fichier de configuration,
+// la version en cours d'écriture et la version précédente
+func (s *jsonStore) Load() (*Config, bool, error) {
+	var best *Config
+	var bestPath string
+	var bestData []byte
+	var lastErr error
+	found := false
+
+	for _, candidate := range []string{s.path, s.path + tmpSuffix, s.path + bakSuffix} {
+		data, err := os.ReadFile(candidate)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		found = true
+		if err != nil {
+			lastErr = fmt.Errorf("failed to read config: %v", err)
+			continue
+		}
+
+		config := newConfig(s.path)
+		if err := parse(data, config); errors.Is(err, ErrNewerSchema) {
+			return nil, false, fmt.Errorf("config %s: %w", candidate, err)
+		} else if err != nil {
+			lastErr = fmt.Errorf("failed to parse config %s: %v", candidate, err)
+			log.Printf("Warning: %v", lastErr)
+			continue
+		}
+		if best == nil || config.Revision > best.Revision {
+			best, bestPath, bestData = config, candidate, data
+		}
+	}
+
+	if best == nil {
+		if found {
+			return nil, false, lastErr
+		}
+		return nil, false, nil
+	}
+
+	s.loaded = bestData
+	recovered := bestPath != s.path
+	if recovered {
+		log.Printf("Warning: config recovered from %s (revision %d)", bestPath, best.Revision)
+	}
+	return best, recovered, nil
+}
+
+// Save écrit le document de façon atomique
+func (s *jsonStore) Save(c *Config) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %v", err)
+	}
+	if err := writeAtomic(s.path, data); err != nil {
+		return fmt.Errorf("failed to write config: %v", err)
+	}
+	return nil
+}
+
+// Backup écrit le document lu par Load
+func (s *jsonStore) Backup(path string) error {
+	return writeSynced(path, s.loaded)
+}
+
+// Close libère le verrou ; chaque sauvegarde ferme son fichier
+func (s *jsonStore) Close() error {
+	if s.lock == nil {
+		return nil
+	}
+	return s.lock.Close()
+}
+
+func (s *jsonStore) String() string {
+	return s.path
+}
+
+// writeAtomic remplace path par data sans jamais laisser de fichier tronqué :
+// écriture d'un fichier temporaire synchronisé, lien de la version précédente
+// vers .bak, puis renommage. Si le renommage est impossible (fichier monté seul
+// dans un conteneur), path est réécrit sur place ; le fichier temporaire et une
+// copie .bak restent intacts pour la reprise.
+func writeAtomic(path string, data []byte) error {
+	tmp := path + tmpSuffix
+	if err := writeSynced(tmp, data); err != nil {
+		return err
+	}
+
+	// Lien vers la version actuelle, qui devient la version précédente sans
+	// rien écrire ; copie si le système de fichiers n'a pas de liens
+	bak := path + bakSuffix
+	os.Remove(bak)
+	if err := os.Link(path, bak); err != nil && !errors.Is(err, os.ErrNotExist) {
+		if err := copySynced(path, bak); err != nil {
+			return fmt.Errorf("failed to keep previous config: %v", err)
+		}
+	}
+
+	if err := rename(tmp, path); err != nil {
+		log.Printf("Warning: cannot replace %s atomically (%v), rewriting it in place", path, err)
+		// Le lien partage le fichier réécrit : il est remplacé par une copie
+		os.Remove(bak)
+		if err := copySynced(path, bak); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to keep previous config: %v", err)
+		}
+		return writeSynced(path, data)
+	}
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// copySynced copie le fichier src dans dst, synchronisé sur le disque
+func copySynced(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return writeSynced(dst, data)
+}
+
+// writeSynced écrit un fichier et attend qu'il soit sur le disque
+func writeSynced(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// syncDir synchronise un répertoire pour rendre un renommage durable ; tous
+// les systèmes ne le permettent pas, l'erreur est ignorée
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
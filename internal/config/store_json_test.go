@@ -0,0 +1,170 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// revisionDoc retourne une configuration JSON de la révision donnée, dont le
+// rolling code de "salon" vaut rolling
+func revisionDoc(revision uint64, rolling uint16) string {
+	return fmt.Sprintf(`{"schema_version": %d, "revision": %d, "remotes": {"salon": {"name": "salon", "address": 1193046, "rolling_code": %d}}}`,
+		SchemaVersion, revision, rolling)
+}
+
+func TestJSONStoreLoadPicksHighestRevision(t *testing.T) {
+	const corrupt = `{"schema_version": 2, "remo`
+
+	tests := []struct {
+		name          string
+		main, tmp, bk string // Contenu des fichiers, absent si vide
+		wantRolling   uint16
+		wantRecovered bool
+	}{
+		{"main only", revisionDoc(4, 40), "", "", 40, false},
+		{"main newer than backup", revisionDoc(4, 40), "", revisionDoc(3, 30), 40, false},
+		{"interrupted before rename", revisionDoc(4, 40), revisionDoc(5, 50), revisionDoc(3, 30), 50, true},
+		{"stale tmp", revisionDoc(4, 40), revisionDoc(3, 30), "", 40, false},
+		{"corrupt main", corrupt, "", revisionDoc(3, 30), 30, true},
+		{"missing main", "", revisionDoc(5, 50), revisionDoc(4, 40), 50, true},
+		{"corrupt tmp", revisionDoc(4, 40), corrupt, revisionDoc(3, 30), 40, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "remotes.json")
+			for suffix, content := range map[string]string{"": tt.main, tmpSuffix: tt.tmp, bakSuffix: tt.bk} {
+				if content == "" {
+					continue
+				}
+				if err := os.WriteFile(path+suffix, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			config, recovered, err := (&jsonStore{path: path}).Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := config.Remotes["salon"].RollingCode; got != tt.wantRolling {
+				t.Errorf("rolling code = %d, want %d", got, tt.wantRolling)
+			}
+			if recovered != tt.wantRecovered {
+				t.Errorf("recovered = %v, want %v", recovered, tt.wantRecovered)
+			}
+		})
+	}
+}
+
+func TestJSONStoreLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	// Support vide : nouvelle configuration, sans erreur
+	config, _, err := (&jsonStore{path: filepath.Join(dir, "absent.json")}).Load()
+	if config != nil || err != nil {
+		t.Errorf("missing config: got %v, %v", config, err)
+	}
+
+	// Aucune version lisible
+	path := filepath.Join(dir, "corrupt.json")
+	os.WriteFile(path, []byte("{"), 0644)
+	os.WriteFile(path+bakSuffix, []byte("not json"), 0644)
+	if _, _, err := (&jsonStore{path: path}).Load(); err == nil {
+		t.Errorf("corrupt config accepted")
+	}
+
+	// Une version plus récente que le programme arrête le chargement, même
+	// si une version lisible existe
+	path = filepath.Join(dir, "newer.json")
+	os.WriteFile(path, []byte(revisionDoc(4, 40)), 0644)
+	os.WriteFile(path+tmpSuffix, []byte(`{"schema_version": 99, "revision": 5}`), 0644)
+	if _, _, err := (&jsonStore{path: path}).Load(); !errors.Is(err, ErrNewerSchema) {
+		t.Errorf("newer schema: err = %v, want ErrNewerSchema", err)
+	}
+}
+
+func TestWriteAtomicKeepsPreviousVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "remotes.json")
+
+	for _, content := range []string{"first", "second"} {
+		if err := writeAtomic(path, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assertContent(t, path, "second")
+	assertContent(t, path+bakSuffix, "first")
+	if _, err := os.Stat(path + tmpSuffix); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("%s left behind: %v", tmpSuffix, err)
+	}
+}
+
+func TestWriteAtomicInPlaceKeepsBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "remotes.json")
+	if err := writeAtomic(path, []byte("first")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Renommage impossible : réécriture sur place, sans toucher à la
+	// version précédente liée à path
+	rename = func(string, string) error { return os.ErrPermission }
+	defer func() { rename = os.Rename }()
+	if err := writeAtomic(path, []byte("second")); err != nil {
+		t.Fatal(err)
+	}
+	assertContent(t, path, "second")
+	assertContent(t, path+bakSuffix, "first")
+	assertContent(t, path+tmpSuffix, "second")
+}
+
+func TestOpenRewritesRecoveredConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "remotes.json")
+	os.WriteFile(path, []byte(revisionDoc(4, 40)), 0644)
+	os.WriteFile(path+tmpSuffix, []byte(revisionDoc(5, 50)), 0644)
+
+	config, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Close()
+
+	reloaded, recovered, err := (&jsonStore{path: path}).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recovered || reloaded.Revision <= 5 || reloaded.Remotes["salon"].RollingCode < 50 {
+		t.Errorf("after recovery: revision %d, rolling code %d, recovered %v",
+			reloaded.Revision, reloaded.Remotes["salon"].RollingCode, recovered)
+	}
+}
+
+func TestJSONStoreLocked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "remotes.json")
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Errorf("config opened twice")
+	}
+
+	// Libéré par Close
+	c.Close()
+	c, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+}
+
+func assertContent(t *testing.T, path, want string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != want {
+		t.Errorf("%s = %q, want %q", filepath.Base(path), data, want)
+	}
+}
@@ -0,0 +1,192 @@
+package config
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Portées des jetons d'API
+const (
+	ScopeRead    = "read"    // Consultation (télécommandes, groupes, programmations, événements)
+	ScopeCommand = "command" // Envoi de commandes
+	ScopeAdmin   = "admin"   // Toutes les opérations, y compris les modifications
+)
+
+// Scopes liste les portées valides
+var Scopes = []string{ScopeRead, ScopeCommand, ScopeAdmin}
+
+// Préfixe des jetons émis, pour les reconnaître dans un fichier ou un log
+const tokenPrefix = "rts_"
+
+// Token représente un jeton d'API. Seule l'empreinte SHA-256 du secret est
+// conservée : le secret n'est affiché qu'à l'émission.
+type Token struct {
+	Name      string    `json:"name"`
+	Hash      string    `json:"hash"` // SHA-256 du secret, en hexadécimal
+	Scopes    []string  `json:"scopes"`
+	Remotes   []string  `json:"remotes,omitempty"` // Télécommandes pilotables ; toutes si vide
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Allows indique si le jeton accorde la portée demandée ; admin les accorde toutes
+func (t *Token) Allows(scope string) bool {
+	return slices.Contains(t.Scopes, ScopeAdmin) || slices.Contains(t.Scopes, scope)
+}
+
+// copy retourne une copie du jeton indépendante de la configuration
+func (t *Token) copy() Token {
+	copied := *t
+	copied.Scopes = append([]string(nil), t.Scopes...)
+	copied.Remotes = append([]string(nil), t.Remotes...)
+	return copied
+}
+
+// ParseScopes valide une liste de portées séparées par des virgules
+func ParseScopes(list string) ([]string, error) {
+	var scopes []string
+	for _, scope := range strings.Split(list, ",") {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if scope == "" {
+			continue
+		}
+		if !slices.Contains(Scopes, scope) {
+			return nil, fmt.Errorf("unknown scope '%s' (use: %s)", scope, strings.Join(Scopes, ", "))
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("at least one scope is required (use: %s)", strings.Join(Scopes, ", "))
+	}
+	return scopes, nil
+}
+
+// hashToken retourne l'empreinte d'un secret
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// ParseRemoteList découpe une liste de télécommandes séparées par des virgules
+func ParseRemoteList(list string) []string {
+	var remotes []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" && !slices.Contains(remotes, name) {
+			remotes = append(remotes, name)
+		}
+	}
+	return remotes
+}
+
+// IssueToken crée un jeton et retourne son secret, qui n'est pas conservé. Si
+// remotes n'est pas vide, le jeton ne peut piloter que ces télécommandes.
+func (c *Config) IssueToken(name string, scopes, remotes []string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("missing token name")
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(Scopes, scope) {
+			return "", fmt.Errorf("unknown scope '%s'", scope)
+		}
+	}
+
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate token: %v", err)
+	}
+	secret := tokenPrefix + base64.RawURLEncoding.EncodeToString(random)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.Tokens[name]; exists {
+		return "", fmt.Errorf("token '%s' %w", name, ErrExists)
+	}
+	for _, remoteName := range remotes {
+		if _, exists := c.Remotes[remoteName]; !exists {
+			return "", fmt.Errorf("remote '%s' %w", remoteName, ErrNotFound)
+		}
+	}
+	c.Tokens[name] = &Token{
+		Name:      name,
+		Hash:      hashToken(secret),
+		Scopes:    append([]string(nil), scopes...),
+		Remotes:   append([]string(nil), remotes...),
+		CreatedAt: time.Now(),
+	}
+
+	if err := c.save(); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// RevokeToken supprime un jeton
+func (c *Config) RevokeToken(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.Tokens[name]; !exists {
+		return fmt.Errorf("token '%s' %w", name, ErrNotFound)
+	}
+	delete(c.Tokens, name)
+
+	return c.save()
+}
+
+// ListTokens retourne les jetons, triés par nom
+func (c *Config) ListTokens() []Token {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	tokens := make([]Token, 0, len(c.Tokens))
+	for _, token := range c.Tokens {
+		tokens = append(tokens, token.copy())
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].Name < tokens[j].Name })
+	return tokens
+}
+
+// HasTokens indique si au moins un jeton est configuré
+func (c *Config) HasTokens() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return len(c.Tokens) > 0
+}
+
+// GetToken retourne un jeton par son nom
+func (c *Config) GetToken(name string) (Token, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	token, exists := c.Tokens[name]
+	if !exists {
+		return Token{}, false
+	}
+	return token.copy(), true
+}
+
+// Authenticate retrouve le jeton correspondant à un secret
+func (c *Config) Authenticate(secret string) (Token, bool) {
+	hash := []byte(hashToken(secret))
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, token := range c.Tokens {
+		if subtle.ConstantTimeCompare(hash, []byte(token.Hash)) == 1 {
+			return token.copy(), true
+		}
+	}
+	return Token{}, false
+}
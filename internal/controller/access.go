@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+
+	"rtscommander/m/internal/remote"
+)
+
+// ErrAccessDenied signale une commande refusée par la liste d'accès d'un principal
+var ErrAccessDenied = errors.New("access denied")
+
+// Origines des commandes, inscrites au journal d'audit
+const (
+	SourceCLI      = "cli"
+	SourceHTTP     = "http"
+	SourceMQTT     = "mqtt"
+	SourceSchedule = "schedule"
+)
+
+// Principal identifie l'auteur des commandes d'un frontal (jeton d'API, pont
+// MQTT, programmation) et les volets qu'il peut piloter
+type Principal struct {
+	Source  string   // Frontal à l'origine des commandes (SourceHTTP...)
+	Name    string   // Identité de l'appelant, vide si elle est inconnue
+	Address string   // Adresse réseau de l'appelant
+	Remotes []string // Télécommandes autorisées ; toutes si la liste est vide
+}
+
+// Restricted indique si le principal est limité à certaines télécommandes
+func (p *Principal) Restricted() bool {
+	return p != nil && len(p.Remotes) > 0
+}
+
+// Allows indique si le principal peut piloter la télécommande d'un volet
+func (p *Principal) Allows(remoteName string) bool {
+	return !p.Restricted() || slices.Contains(p.Remotes, remoteName)
+}
+
+// name retourne le nom du principal, vide pour un appel interne
+func (p *Principal) name() string {
+	if p == nil {
+		return ""
+	}
+	return p.Name
+}
+
+type principalKey struct{}
+
+// principalFrom retourne le principal associé à ctx, nil pour un appel interne
+// (CLI, programmations)
+func principalFrom(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// authorize vérifie que le principal de ctx peut envoyer command sur une
+// télécommande. Une télécommande de groupe n'est autorisée que si chacun des
+// volets appairés avec elle l'est. Les refus sont journalisés et publiés.
+func (ctrl *Controller) authorize(ctx context.Context, remoteName, command string) error {
+	p := principalFrom(ctx)
+	if !p.Restricted() {
+		return nil
+	}
+
+	allowed := p.Allows(remoteName)
+	if members := ctrl.config.MembersControlledBy(remoteName); len(members) > 0 {
+		allowed = !slices.ContainsFunc(members, func(member string) bool { return !p.Allows(member) })
+	}
+	if allowed {
+		return nil
+	}
+
+	err := fmt.Errorf("%w: '%s' may not control remote '%s'", ErrAccessDenied, p.Name, remoteName)
+	log.Printf("[%s] Commande '%s' refusée pour '%s'", remoteName, command, p.Name)
+	ctrl.record(ctx, remoteName, command, nil, err)
+	ctrl.publish(Event{Type: EventAccessDenied, Remote: remoteName, Command: command, Principal: p.Name})
+	return err
+}
+
+// Session pilote les volets au nom d'un principal : le contrôleur applique sa
+// liste d'accès à chaque commande, quel que soit le frontal
+type Session struct {
+	ctrl *Controller
+	ctx  context.Context
+}
+
+// As retourne une session agissant au nom de p ; un principal nil n'est
+// soumis à aucune restriction
+func (ctrl *Controller) As(p *Principal) *Session {
+	return &Session{ctrl: ctrl, ctx: context.WithValue(context.Background(), principalKey{}, p)}
+}
+
+// Send envoie une commande avec le nombre de répétitions qui lui est propre
+func (s *Session) Send(remoteName string, cmd remote.Command) error {
+	return s.ctrl.sendCommand(s.ctx, remoteName, cmd.Code, SendOptions{Repeats: cmd.Repeats})
+}
+
+// SendCommandWithOptions envoie une commande en simulant un bouton maintenu
+func (s *Session) SendCommandWithOptions(remoteName string, command byte, opts SendOptions) error {
+	return s.ctrl.sendCommand(s.ctx, remoteName, command, opts)
+}
+
+// StartPosition lance le déplacement d'un volet vers target
+func (s *Session) StartPosition(remoteName string, target int) (<-chan error, error) {
+	return s.ctrl.startPosition(s.ctx, remoteName, target)
+}
+
+// SendGroup envoie une commande à un groupe ; les membres refusés figurent en
+// échec dans les résultats
+func (s *Session) SendGroup(groupName string, command byte, opts SendOptions) ([]MemberResult, error) {
+	return s.ctrl.sendGroup(s.ctx, groupName, command, opts)
+}
+
+// StartGroupPosition lance le déplacement de chaque membre autorisé d'un groupe
+func (s *Session) StartGroupPosition(groupName string, target int) ([]MemberResult, error) {
+	return s.ctrl.startGroupPosition(s.ctx, groupName, target)
+}
+
+// Run exécute une commande nommée sur une télécommande ou sur un groupe
+func (s *Session) Run(remoteName, groupName, command string, value *int) ([]MemberResult, error) {
+	return s.ctrl.run(s.ctx, remoteName, groupName, command, value)
+}
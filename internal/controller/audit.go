@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"rtscommander/m/internal/audit"
+)
+
+// SetAuditLog inscrit chaque émission et chaque refus dans auditLog ; à
+// appeler avant la première commande
+func (ctrl *Controller) SetAuditLog(auditLog audit.History) {
+	ctrl.audit = auditLog
+}
+
+// AuditLog retourne le journal d'audit du contrôleur, nil s'il est désactivé
+func (ctrl *Controller) AuditLog() audit.History {
+	return ctrl.audit
+}
+
+// record inscrit une commande au journal d'audit avec l'origine et l'identité
+// du principal de ctx ; sans principal, la commande vient de la CLI
+func (ctrl *Controller) record(ctx context.Context, remoteName, command string, rollingCode *uint16, err error) {
+	if ctrl.audit == nil {
+		return
+	}
+	entry := audit.Entry{
+		Remote:      remoteName,
+		Command:     command,
+		RollingCode: rollingCode,
+		Source:      SourceCLI,
+		Result:      audit.ResultOK,
+	}
+	if p := principalFrom(ctx); p != nil {
+		entry.Source, entry.Principal, entry.Address = p.Source, p.Name, p.Address
+	}
+	switch {
+	case errors.Is(err, ErrAccessDenied):
+		entry.Result, entry.Error = audit.ResultDenied, err.Error()
+	case err != nil:
+		entry.Result, entry.Error = audit.ResultError, err.Error()
+	}
+
+	if err := ctrl.audit.Record(entry); err != nil {
+		log.Printf("Warning: failed to write audit log: %v", err)
+	}
+}
@@ -1,13 +1,14 @@
 package controller
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
 	"time"
 
-	"periph.io/x/conn/v3/spi"
-
+	"rtscommander/m/internal/audit"
 	"rtscommander/m/internal/config"
 	"rtscommander/m/internal/radio"
 	"rtscommander/m/internal/remote"
@@ -16,83 +17,286 @@ import (
 // Controller gère l'envoi de commandes RTS
 type Controller struct {
 	config *config.Config
-	conn   spi.Conn
+	tx     radio.Transmitter
 	mu     sync.Mutex
+
+	// Estimation des positions et déplacements vers une position
+	posMu   sync.Mutex
+	motions map[string]*motion
+	moves   map[string]*move
+
+	// Abonnés aux événements (MQTT, flux /events)
+	events events
+
+	// Journal d'audit des émissions (nil = désactivé)
+	audit audit.History
 }
 
 // New crée un nouveau contrôleur
-func New(cfg *config.Config, conn spi.Conn) *Controller {
+func New(cfg *config.Config, tx radio.Transmitter) *Controller {
 	return &Controller{
-		config: cfg,
-		conn:   conn,
+		config:  cfg,
+		tx:      tx,
+		motions: make(map[string]*motion),
+		moves:   make(map[string]*move),
 	}
 }
 
-// SendCommand envoie une commande RTS complète
+// Durée d'appui maximale acceptée pour une commande
+const MaxHold = 30 * time.Second
+
+// ErrInvalidOptions signale une durée d'appui ou un nombre de répétitions hors limites
+var ErrInvalidOptions = errors.New("invalid send options")
+
+// SendOptions précise la durée d'appui d'une commande
+type SendOptions struct {
+	Repeats int           // Répétitions après la première trame
+	Hold    time.Duration // Durée d'appui simulée, prioritaire sur Repeats
+}
+
+// repeats retourne le nombre de répétitions correspondant aux options
+func (opts SendOptions) repeats() (int, error) {
+	if opts.Hold < 0 || opts.Hold > MaxHold {
+		return 0, fmt.Errorf("%w: hold duration %v (max %v)", ErrInvalidOptions, opts.Hold, MaxHold)
+	}
+	if opts.Hold > 0 {
+		return remote.RepeatsFor(opts.Hold), nil
+	}
+	if opts.Repeats < 0 || opts.Repeats > remote.RepeatsFor(MaxHold) {
+		return 0, fmt.Errorf("%w: repeat count %d (max %d)", ErrInvalidOptions, opts.Repeats, remote.RepeatsFor(MaxHold))
+	}
+	return opts.Repeats, nil
+}
+
+// Validate vérifie les options avant l'envoi
+func (opts SendOptions) Validate() error {
+	_, err := opts.repeats()
+	return err
+}
+
+// SendCommand envoie une commande RTS complète (appui bref)
 func (ctrl *Controller) SendCommand(remoteName string, command byte) error {
+	return ctrl.SendCommandWithOptions(remoteName, command, SendOptions{Repeats: remote.DefaultRepeats})
+}
+
+// Send envoie une commande avec le nombre de répétitions qui lui est propre
+func (ctrl *Controller) Send(remoteName string, cmd remote.Command) error {
+	return ctrl.SendCommandWithOptions(remoteName, cmd.Code, SendOptions{Repeats: cmd.Repeats})
+}
+
+// SendCommandWithOptions envoie une commande en simulant un bouton maintenu.
+// Toutes les trames d'un même appui portent le même rolling code, qui n'est
+// incrémenté qu'une fois l'appui terminé. Un déplacement vers une position en
+// cours sur cette télécommande (ou sur les volets d'un groupe qu'elle commande)
+// est annulé.
+func (ctrl *Controller) SendCommandWithOptions(remoteName string, command byte, opts SendOptions) error {
+	return ctrl.sendCommand(context.Background(), remoteName, command, opts)
+}
+
+// sendCommand envoie une commande si le principal de ctx y est autorisé
+func (ctrl *Controller) sendCommand(ctx context.Context, remoteName string, command byte, opts SendOptions) error {
+	if err := ctrl.authorize(ctx, remoteName, remote.CommandName(command)); err != nil {
+		return err
+	}
+
+	ctrl.cancelMove(remoteName)
+	for _, member := range ctrl.config.MembersControlledBy(remoteName) {
+		ctrl.cancelMove(member)
+	}
+	return ctrl.send(ctx, remoteName, command, opts)
+}
+
+// send émet une commande, sauf si ctx est annulé avant l'accès à l'émetteur
+func (ctrl *Controller) send(ctx context.Context, remoteName string, command byte, opts SendOptions) error {
+	repeats, err := opts.repeats()
+	if err != nil {
+		return err
+	}
+
 	ctrl.mu.Lock()
 	defer ctrl.mu.Unlock()
 
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	// Récupérer la télécommande
-	rc, exists := ctrl.config.GetRemote(remoteName)
+	rc, exists := ctrl.config.Snapshot(remoteName)
 
 	if !exists {
-		return fmt.Errorf("remote '%s' not found", remoteName)
+		err := fmt.Errorf("remote '%s' %w", remoteName, config.ErrNotFound)
+		ctrl.record(ctx, remoteName, remote.CommandName(command), nil, err)
+		return err
 	}
 
-	// Créer la trame
+	// Préparer l'émetteur
+	if err := ctrl.tx.Prepare(); err != nil {
+		ctrl.record(ctx, remoteName, remote.CommandName(command), nil, err)
+		ctrl.publish(Event{Type: EventRadioError, Remote: remoteName, Error: err.Error()})
+		return err
+	}
+
+	// Réserver le rolling code : la borne de son bloc est sur le disque avant
+	// l'émission, il ne peut pas être réutilisé après une coupure de courant
+	usedCode, err := ctrl.config.ReserveRollingCode(remoteName)
+	if err != nil {
+		ctrl.record(ctx, remoteName, remote.CommandName(command), nil, err)
+		return err
+	}
+	rc.RollingCode = usedCode
+
+	// Créer la trame et sa forme d'onde (réveil + trame + répétitions)
 	frame := rc.BuildRTSFrame(command)
+	waveform := remote.BuildWaveform(frame, repeats)
 
-	// Préambule et sync word Somfy
-	preamble := []byte{0xAA, 0xAA, 0xAA, 0xAA, 0xAA, 0xAA} // Préambule
-	syncWord := []byte{0xA0}                               // Hardware sync
+	// Émettre la forme d'onde complète. En cas d'échec, une partie des trames a
+	// pu être reçue : le rolling code est tout de même consommé.
+	sentAt := time.Now()
+	txErr := ctrl.tx.Transmit(waveform)
 
-	// Encoder en Manchester
-	encodedFrame := remote.ManchesterEncode(frame)
+	nextCode := usedCode + 1
+	ctrl.publish(Event{Type: EventRollingCode, Remote: remoteName, RollingCode: &nextCode})
 
-	// Construire la trame complète
-	fullFrame := append(preamble, syncWord...)
-	fullFrame = append(fullFrame, encodedFrame...)
+	if txErr != nil {
+		err := fmt.Errorf("failed to transmit frame: %v", txErr)
+		ctrl.record(ctx, remoteName, remote.CommandName(command), &usedCode, err)
+		ctrl.publish(Event{Type: EventRadioError, Remote: remoteName, Error: txErr.Error()})
+		return err
+	}
+	ctrl.record(ctx, remoteName, remote.CommandName(command), &usedCode, nil)
 
-	// Mettre le CC1101 en mode idle
-	if err := radio.WriteStrobe(ctrl.conn, radio.SIDLE); err != nil {
-		return fmt.Errorf("failed to set idle mode: %v", err)
+	// Mettre à jour l'estimation de position, y compris celle des volets
+	// appairés avec cette télécommande de groupe
+	ctrl.trackCommand(remoteName, command, repeats, sentAt)
+	for _, member := range ctrl.config.MembersControlledBy(remoteName) {
+		ctrl.trackCommand(member, command, repeats, sentAt)
 	}
-	time.Sleep(10 * time.Millisecond)
 
-	// Vider le FIFO TX
-	if err := radio.WriteStrobe(ctrl.conn, radio.SFTX); err != nil {
-		return fmt.Errorf("failed to flush TX FIFO: %v", err)
+	ctrl.publish(Event{
+		Type:      EventCommand,
+		Remote:    remoteName,
+		Time:      sentAt,
+		Command:   remote.CommandName(command),
+		Principal: principalFrom(ctx).name(),
+	})
+
+	log.Printf("[%s] Commande 0x%X envoyée (rolling code: %d, répétitions: %d)", remoteName, command, usedCode, repeats)
+	return nil
+}
+
+// AddRemote ajoute ou remplace une télécommande
+func (ctrl *Controller) AddRemote(rc *remote.Control) error {
+	ctrl.mu.Lock()
+	err := ctrl.config.AddRemote(rc.Name, rc)
+	ctrl.mu.Unlock()
+	if err != nil {
+		return err
 	}
+	ctrl.publish(Event{Type: EventRemoteAdded, Remote: rc.Name})
+	return nil
+}
 
-	// Envoyer la trame (répétition standard Somfy : 2 trames complètes + 7 répétitions)
-	for i := 0; i < 2; i++ {
-		if err := radio.TransmitFrame(ctrl.conn, fullFrame); err != nil {
-			return fmt.Errorf("failed to send frame %d: %v", i+1, err)
-		}
-		time.Sleep(30 * time.Millisecond)
+// UpdateRemote modifie une télécommande ; l'émission en cours éventuelle est
+// attendue pour ne pas perdre l'incrément du rolling code
+func (ctrl *Controller) UpdateRemote(name string, update func(rc *remote.Control)) error {
+	ctrl.mu.Lock()
+	err := ctrl.config.UpdateRemote(name, update)
+	ctrl.mu.Unlock()
+	if err != nil {
+		return err
 	}
 
-	// Répétitions avec inter-frame spacing
-	for i := 0; i < 7; i++ {
-		if err := radio.TransmitFrame(ctrl.conn, fullFrame); err != nil {
-			return fmt.Errorf("failed to send repeat %d: %v", i+1, err)
-		}
-		time.Sleep(30 * time.Millisecond)
+	ctrl.publish(Event{Type: EventRemoteAdded, Remote: name})
+	return nil
+}
+
+// RenameRemote renomme une télécommande. Un déplacement en cours est abandonné :
+// l'estimation repart de la dernière position enregistrée.
+func (ctrl *Controller) RenameRemote(name, newName string) error {
+	ctrl.mu.Lock()
+	err := ctrl.config.RenameRemote(name, newName)
+	ctrl.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	ctrl.forget(name)
+	ctrl.publish(Event{Type: EventRemoteRemoved, Remote: name})
+	ctrl.publish(Event{Type: EventRemoteAdded, Remote: newName})
+	return nil
+}
+
+// PatchRemote renomme (si newName diffère de name) puis modifie une
+// télécommande en une seule opération, voir config.PatchRemote
+func (ctrl *Controller) PatchRemote(name, newName string, update func(rc *remote.Control)) error {
+	ctrl.mu.Lock()
+	err := ctrl.config.PatchRemote(name, newName, update)
+	ctrl.mu.Unlock()
+	if err != nil {
+		return err
 	}
 
-	// Incrémenter le rolling code
-	rc.RollingCode++
+	if newName != name {
+		ctrl.forget(name)
+		ctrl.publish(Event{Type: EventRemoteRemoved, Remote: name})
+	}
+	ctrl.publish(Event{Type: EventRemoteAdded, Remote: newName})
+	return nil
+}
 
-	// Sauvegarder la configuration
-	if err := ctrl.config.Save(); err != nil {
-		log.Printf("Warning: failed to save config: %v", err)
+// RemoveRemote supprime une télécommande et oublie son estimation de position
+func (ctrl *Controller) RemoveRemote(name string) error {
+	ctrl.mu.Lock()
+	err := ctrl.config.RemoveRemote(name)
+	ctrl.mu.Unlock()
+	if err != nil {
+		return err
 	}
 
-	log.Printf("[%s] Commande 0x%X envoyée (rolling code: %d)", remoteName, command, rc.RollingCode-1)
+	ctrl.forget(name)
+	ctrl.publish(Event{Type: EventRemoteRemoved, Remote: name})
 	return nil
 }
 
+// Restore remplace la configuration par une sauvegarde (voir config.Restore).
+// Les déplacements et estimations en cours sont oubliés, et les télécommandes
+// republiées pour que les frontaux se mettent à jour.
+func (ctrl *Controller) Restore(data []byte, force bool) (*config.RestoreReport, error) {
+	before := ctrl.config.ListRemotes()
+
+	ctrl.mu.Lock()
+	report, err := ctrl.config.Restore(data, force)
+	ctrl.mu.Unlock()
+	if err != nil {
+		return report, err
+	}
+
+	for _, name := range before {
+		ctrl.forget(name)
+		if _, exists := ctrl.config.Snapshot(name); !exists {
+			ctrl.publish(Event{Type: EventRemoteRemoved, Remote: name})
+		}
+	}
+	for _, name := range ctrl.config.ListRemotes() {
+		ctrl.publish(Event{Type: EventRemoteAdded, Remote: name})
+	}
+	log.Printf("Configuration restaurée : %d télécommande(s), %d groupe(s), %d programmation(s)", report.Remotes, report.Groups, report.Schedules)
+	return report, nil
+}
+
+// forget annule le déplacement et l'estimation en cours d'une télécommande
+func (ctrl *Controller) forget(name string) {
+	ctrl.cancelMove(name)
+
+	ctrl.posMu.Lock()
+	defer ctrl.posMu.Unlock()
+
+	if m, moving := ctrl.motions[name]; moving {
+		m.timer.Stop()
+		delete(ctrl.motions, name)
+	}
+}
+
 // Config retourne la configuration du contrôleur
 func (ctrl *Controller) Config() *config.Config {
 	return ctrl.config
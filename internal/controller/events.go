@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"sync"
+	"time"
+)
+
+// Types d'événements publiés par le contrôleur
+const (
+	EventCommand       = "command"        // Commande émise
+	EventState         = "state"          // Position estimée ou déplacement modifié
+	EventRollingCode   = "rolling_code"   // Rolling code incrémenté
+	EventRemoteAdded   = "remote_added"   // Télécommande ajoutée ou remplacée
+	EventRemoteRemoved = "remote_removed" // Télécommande supprimée
+	EventRadioError    = "radio_error"    // Échec de l'émetteur radio
+	EventAccessDenied  = "access_denied"  // Commande refusée par une liste d'accès
+)
+
+// Taille du tampon de chaque abonné : un abonné trop lent perd des événements
+// plutôt que de bloquer l'émission
+const eventBuffer = 64
+
+// Event décrit un changement survenu sur une télécommande
+type Event struct {
+	Type    string    `json:"type"`
+	Remote  string    `json:"remote,omitempty"`
+	Time    time.Time `json:"time"`
+	Command string    `json:"command,omitempty"` // Commande émise ou refusée
+
+	// Auteur de la commande (EventCommand, EventAccessDenied) : jeton d'API,
+	// pont MQTT, programmation, ou vide pour la CLI et l'API sans jeton
+	Principal string `json:"principal,omitempty"`
+
+	RollingCode *uint16 `json:"rolling_code,omitempty"` // Prochain rolling code (EventRollingCode)
+	Position    *int    `json:"position,omitempty"`     // Position estimée (EventState)
+	Moving      string  `json:"moving,omitempty"`       // Sens du déplacement en cours (EventState)
+	Error       string  `json:"error,omitempty"`        // Message d'erreur (EventRadioError)
+}
+
+// events diffuse les événements du contrôleur à ses abonnés
+type events struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+}
+
+// Subscribe abonne l'appelant aux événements du contrôleur. La fonction
+// retournée met fin à l'abonnement et ferme le canal.
+func (ctrl *Controller) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, eventBuffer)
+
+	ctrl.events.mu.Lock()
+	if ctrl.events.subscribers == nil {
+		ctrl.events.subscribers = make(map[chan Event]struct{})
+	}
+	ctrl.events.subscribers[ch] = struct{}{}
+	ctrl.events.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			ctrl.events.mu.Lock()
+			delete(ctrl.events.subscribers, ch)
+			ctrl.events.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// publish diffuse un événement sans jamais bloquer
+func (ctrl *Controller) publish(ev Event) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+
+	ctrl.events.mu.Lock()
+	defer ctrl.events.mu.Unlock()
+
+	for ch := range ctrl.events.subscribers {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"rtscommander/m/internal/config"
+	"rtscommander/m/internal/remote"
+)
+
+// Pause entre les émissions destinées à deux membres d'un groupe
+const GroupMemberGap = 100 * time.Millisecond
+
+// MemberResult représente le résultat d'une commande pour un membre d'un groupe
+type MemberResult struct {
+	Remote  string `json:"remote"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+	Denied  bool   `json:"denied,omitempty"` // Échec dû à la liste d'accès
+}
+
+// Délais de la procédure d'appairage d'une télécommande de groupe
+const (
+	pairProgHold = 3 * time.Second         // PROG maintenu sur la télécommande du membre
+	pairDelay    = 1500 * time.Millisecond // Attente du va-et-vient du moteur
+)
+
+// SendGroup envoie une commande à un groupe : en une seule émission via sa
+// télécommande de groupe si elle existe, sinon à chaque membre l'un après l'autre
+func (ctrl *Controller) SendGroup(groupName string, command byte, opts SendOptions) ([]MemberResult, error) {
+	return ctrl.sendGroup(context.Background(), groupName, command, opts)
+}
+
+// sendGroup envoie une commande à un groupe au nom du principal de ctx
+func (ctrl *Controller) sendGroup(ctx context.Context, groupName string, command byte, opts SendOptions) ([]MemberResult, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	group, exists := ctrl.config.GetGroup(groupName)
+	if !exists {
+		return nil, fmt.Errorf("group '%s' %w", groupName, config.ErrNotFound)
+	}
+
+	if group.Remote == "" {
+		return ctrl.forEachMember(groupName, func(member string) error {
+			return ctrl.sendCommand(ctx, member, command, opts)
+		})
+	}
+
+	// Émission unique : le résultat est le même pour tous les membres
+	err := ctrl.sendCommand(ctx, group.Remote, command, opts)
+	results := make([]MemberResult, 0, len(group.Members))
+	for _, member := range group.Members {
+		result := MemberResult{Remote: member, Success: err == nil}
+		if err != nil {
+			result.Error = err.Error()
+			result.Denied = errors.Is(err, ErrAccessDenied)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// PairGroupRemote appaire la télécommande de groupe avec le moteur de chaque
+// membre (ou du seul membre only s'il est renseigné) : PROG maintenu sur la
+// télécommande du membre pour passer le moteur en programmation, puis PROG bref
+// sur la télécommande de groupe. Comme avec une télécommande Somfy, répéter la
+// procédure sur un moteur déjà appairé retire la télécommande de groupe.
+func (ctrl *Controller) PairGroupRemote(groupName, only string) ([]MemberResult, error) {
+	group, exists := ctrl.config.GetGroup(groupName)
+	if !exists {
+		return nil, fmt.Errorf("group '%s' %w", groupName, config.ErrNotFound)
+	}
+	if group.Remote == "" {
+		return nil, fmt.Errorf("group '%s' has no group remote", groupName)
+	}
+	if only != "" && !slices.Contains(group.Members, only) {
+		return nil, fmt.Errorf("remote '%s' is not a member of group '%s'", only, groupName)
+	}
+
+	members := group.Members
+	if only != "" {
+		members = []string{only}
+	}
+
+	return ctrl.forEach(members, func(member string) error {
+		if err := ctrl.SendCommandWithOptions(member, remote.CmdProg, SendOptions{Hold: pairProgHold}); err != nil {
+			return fmt.Errorf("failed to enter programming mode: %v", err)
+		}
+		time.Sleep(pairDelay)
+
+		if err := ctrl.SendCommand(group.Remote, remote.CmdProg); err != nil {
+			return fmt.Errorf("failed to register group remote: %v", err)
+		}
+		time.Sleep(pairDelay)
+		return nil
+	})
+}
+
+// StartGroupPosition lance le déplacement de chaque membre d'un groupe vers target
+func (ctrl *Controller) StartGroupPosition(groupName string, target int) ([]MemberResult, error) {
+	return ctrl.startGroupPosition(context.Background(), groupName, target)
+}
+
+// startGroupPosition lance les déplacements d'un groupe au nom du principal de ctx
+func (ctrl *Controller) startGroupPosition(ctx context.Context, groupName string, target int) ([]MemberResult, error) {
+	return ctrl.forEachMember(groupName, func(member string) error {
+		_, err := ctrl.startPosition(ctx, member, target)
+		return err
+	})
+}
+
+// SetGroupPosition déplace tous les membres d'un groupe vers target et attend
+// la fin de chaque déplacement
+func (ctrl *Controller) SetGroupPosition(groupName string, target int) ([]MemberResult, error) {
+	dones := make(map[string]<-chan error)
+	results, err := ctrl.forEachMember(groupName, func(member string) error {
+		done, err := ctrl.StartPosition(member, target)
+		if err == nil {
+			dones[member] = done
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range results {
+		done, started := dones[results[i].Remote]
+		if !started {
+			continue
+		}
+		if err := <-done; err != nil {
+			results[i].Success = false
+			results[i].Error = err.Error()
+		}
+	}
+	return results, nil
+}
+
+// forEachMember applique fn à chaque membre d'un groupe et collecte les résultats
+func (ctrl *Controller) forEachMember(groupName string, fn func(member string) error) ([]MemberResult, error) {
+	group, exists := ctrl.config.GetGroup(groupName)
+	if !exists {
+		return nil, fmt.Errorf("group '%s' %w", groupName, config.ErrNotFound)
+	}
+	return ctrl.forEach(group.Members, fn)
+}
+
+// forEach applique fn à chaque télécommande, espacées de GroupMemberGap
+func (ctrl *Controller) forEach(members []string, fn func(member string) error) ([]MemberResult, error) {
+	results := make([]MemberResult, 0, len(members))
+	for i, member := range members {
+		if i > 0 {
+			time.Sleep(GroupMemberGap)
+		}
+
+		result := MemberResult{Remote: member, Success: true}
+		if err := fn(member); err != nil {
+			result.Success = false
+			result.Error = err.Error()
+			result.Denied = errors.Is(err, ErrAccessDenied)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// AllDenied indique si tous les échecs sont dus à la liste d'accès
+func AllDenied(results []MemberResult) bool {
+	for _, result := range results {
+		if !result.Success && !result.Denied {
+			return false
+		}
+	}
+	return true
+}
+
+// AllSucceeded indique si la commande a réussi pour tous les membres
+func AllSucceeded(results []MemberResult) bool {
+	for _, result := range results {
+		if !result.Success {
+			return false
+		}
+	}
+	return true
+}
+
+// Run exécute une commande nommée (commande RTS ou "position") sur une
+// télécommande ou sur un groupe. Les déplacements vers une position sont lancés
+// en arrière-plan.
+func (ctrl *Controller) Run(remoteName, groupName, command string, value *int) ([]MemberResult, error) {
+	return ctrl.run(context.Background(), remoteName, groupName, command, value)
+}
+
+// run exécute une commande nommée au nom du principal de ctx
+func (ctrl *Controller) run(ctx context.Context, remoteName, groupName, command string, value *int) ([]MemberResult, error) {
+	if (remoteName == "") == (groupName == "") {
+		return nil, fmt.Errorf("either a remote or a group is required")
+	}
+
+	if strings.EqualFold(command, PositionCommand) {
+		if value == nil {
+			return nil, fmt.Errorf("missing value for position command")
+		}
+		if groupName != "" {
+			return ctrl.startGroupPosition(ctx, groupName, *value)
+		}
+		_, err := ctrl.startPosition(ctx, remoteName, *value)
+		return []MemberResult{{Remote: remoteName, Success: err == nil}}, err
+	}
+
+	cmd, ok := remote.ParseCommand(command)
+	if !ok {
+		return nil, fmt.Errorf("unknown command: %s", command)
+	}
+	opts := SendOptions{Repeats: cmd.Repeats}
+	if groupName != "" {
+		return ctrl.sendGroup(ctx, groupName, cmd.Code, opts)
+	}
+	err := ctrl.sendCommand(ctx, remoteName, cmd.Code, opts)
+	return []MemberResult{{Remote: remoteName, Success: err == nil}}, err
+}
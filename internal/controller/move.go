@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"rtscommander/m/internal/config"
+	"rtscommander/m/internal/remote"
+)
+
+// Nom de la commande de déplacement vers une position (CLI et API)
+const PositionCommand = "position"
+
+// move représente un déplacement vers une position en cours
+type move struct {
+	cancel context.CancelFunc
+}
+
+// StartPosition lance le déplacement d'un volet vers target (0-100 %) : UP ou
+// DOWN, attente du temps de course calculé, puis MY. Le déplacement s'exécute en
+// arrière-plan ; done reçoit son résultat. Toute nouvelle commande sur la
+// télécommande l'annule.
+func (ctrl *Controller) StartPosition(remoteName string, target int) (<-chan error, error) {
+	return ctrl.startPosition(context.Background(), remoteName, target)
+}
+
+// startPosition lance un déplacement si le principal de ctx y est autorisé. Le
+// déplacement conserve le principal mais survit à l'annulation de ctx.
+func (ctrl *Controller) startPosition(ctx context.Context, remoteName string, target int) (<-chan error, error) {
+	if target < PositionClosed || target > PositionOpen {
+		return nil, fmt.Errorf("invalid position %d (expected %d-%d)", target, PositionClosed, PositionOpen)
+	}
+
+	rc, exists := ctrl.config.Snapshot(remoteName)
+	if !exists {
+		return nil, fmt.Errorf("remote '%s' %w", remoteName, config.ErrNotFound)
+	}
+	if !rc.TracksPosition() {
+		return nil, fmt.Errorf("remote '%s' has no travel times, position is unknown", remoteName)
+	}
+	if err := ctrl.authorize(ctx, remoteName, PositionCommand); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	m := &move{cancel: cancel}
+
+	ctrl.posMu.Lock()
+	if previous, running := ctrl.moves[remoteName]; running {
+		previous.cancel()
+	}
+	ctrl.moves[remoteName] = m
+	ctrl.posMu.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		err := ctrl.runMove(ctx, remoteName, target)
+
+		ctrl.posMu.Lock()
+		if ctrl.moves[remoteName] == m {
+			delete(ctrl.moves, remoteName)
+		}
+		ctrl.posMu.Unlock()
+		cancel()
+
+		if err != nil && err != context.Canceled {
+			log.Printf("[%s] Déplacement vers %d%% interrompu: %v", remoteName, target, err)
+		}
+		done <- err
+	}()
+	return done, nil
+}
+
+// SetPosition déplace un volet vers target et attend la fin du déplacement
+func (ctrl *Controller) SetPosition(remoteName string, target int) error {
+	done, err := ctrl.StartPosition(remoteName, target)
+	if err != nil {
+		return err
+	}
+	return <-done
+}
+
+// runMove exécute un déplacement vers une position
+func (ctrl *Controller) runMove(ctx context.Context, remoteName string, target int) error {
+	state, exists := ctrl.State(remoteName)
+	if !exists {
+		return fmt.Errorf("remote '%s' %w", remoteName, config.ErrNotFound)
+	}
+
+	// Position inconnue : ouverture complète pour repartir d'une position sûre
+	if state.Position == nil {
+		log.Printf("[%s] Position inconnue, ouverture complète avant le déplacement", remoteName)
+		if err := ctrl.moveFor(ctx, remoteName, remote.CmdUp, time.Duration(state.TravelUpMs)*time.Millisecond); err != nil {
+			return err
+		}
+		if state, exists = ctrl.State(remoteName); !exists || state.Position == nil {
+			return fmt.Errorf("remote '%s' position still unknown", remoteName)
+		}
+	}
+
+	current := *state.Position
+	switch {
+	case target == current && state.Moving == "":
+		return nil
+	case target == PositionOpen, target == PositionClosed:
+		// Les fins de course arrêtent le moteur : pas besoin de MY
+		command := byte(remote.CmdUp)
+		if target == PositionClosed {
+			command = remote.CmdDown
+		}
+		return ctrl.send(ctx, remoteName, command, SendOptions{Repeats: remote.DefaultRepeats})
+	case target == current:
+		// Volet en mouvement à la position demandée : arrêt immédiat
+		return ctrl.send(ctx, remoteName, remote.CmdMy, SendOptions{Repeats: remote.DefaultRepeats})
+	}
+
+	command := byte(remote.CmdUp)
+	travel := time.Duration(state.TravelUpMs) * time.Millisecond
+	if target < current {
+		command = remote.CmdDown
+		travel = time.Duration(state.TravelDownMs) * time.Millisecond
+	}
+	distance := target - current
+	if distance < 0 {
+		distance = -distance
+	}
+	if err := ctrl.moveFor(ctx, remoteName, command, travel*time.Duration(distance)/100); err != nil {
+		return err
+	}
+
+	// Arrêt à la position demandée
+	return ctrl.send(ctx, remoteName, remote.CmdMy, SendOptions{Repeats: remote.DefaultRepeats})
+}
+
+// moveFor envoie command puis attend duration à partir du début de l'émission
+func (ctrl *Controller) moveFor(ctx context.Context, remoteName string, command byte, duration time.Duration) error {
+	start := time.Now()
+	if err := ctrl.send(ctx, remoteName, command, SendOptions{Repeats: remote.DefaultRepeats}); err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(time.Until(start.Add(duration)))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// cancelMove annule le déplacement vers une position en cours sur une télécommande
+func (ctrl *Controller) cancelMove(remoteName string) {
+	ctrl.posMu.Lock()
+	defer ctrl.posMu.Unlock()
+
+	if m, running := ctrl.moves[remoteName]; running {
+		m.cancel()
+		delete(ctrl.moves, remoteName)
+	}
+}
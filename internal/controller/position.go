@@ -0,0 +1,241 @@
+package controller
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"time"
+
+	"rtscommander/m/internal/remote"
+)
+
+// Positions extrêmes d'un volet (en %)
+const (
+	PositionClosed = 0
+	PositionOpen   = 100
+)
+
+// Appui sur My au-delà duquel le moteur enregistre la position favorite
+const myStoreHold = 3 * time.Second
+
+// motion représente un déplacement estimé en cours
+type motion struct {
+	from, to float64
+	start    time.Time
+	duration time.Duration
+	timer    *time.Timer
+}
+
+// position retourne la position estimée à l'instant now
+func (m *motion) position(now time.Time) float64 {
+	if m.duration <= 0 {
+		return m.to
+	}
+	progress := float64(now.Sub(m.start)) / float64(m.duration)
+	progress = math.Max(0, math.Min(1, progress))
+	return m.from + (m.to-m.from)*progress
+}
+
+// direction retourne le sens du déplacement : "up", "down" ou ""
+func (m *motion) direction() string {
+	switch {
+	case m.to > m.from:
+		return "up"
+	case m.to < m.from:
+		return "down"
+	}
+	return ""
+}
+
+// RemoteState représente une télécommande avec la position estimée de son volet
+type RemoteState struct {
+	remote.Control
+	Moving   string `json:"moving,omitempty"`   // Sens du déplacement en cours
+	Redacted bool   `json:"redacted,omitempty"` // Secrets d'appairage omis du JSON
+}
+
+// Champs qui permettent d'imiter une télécommande auprès des moteurs appairés
+var pairingFields = []string{"address", "rolling_code", "encryption_key"}
+
+// Redact retourne une copie de l'état dont le JSON omet l'adresse, le
+// rolling code et la clé, et l'indique par "redacted": true
+func (s *RemoteState) Redact() *RemoteState {
+	redacted := *s
+	redacted.Redacted = true
+	return &redacted
+}
+
+// MarshalJSON encode l'état, sans ses secrets d'appairage s'il est masqué
+func (s RemoteState) MarshalJSON() ([]byte, error) {
+	type plain RemoteState
+	data, err := json.Marshal(plain(s))
+	if err != nil || !s.Redacted {
+		return data, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for _, field := range pairingFields {
+		delete(fields, field)
+	}
+	return json.Marshal(fields)
+}
+
+// State retourne l'état d'une télécommande, position estimée à l'instant présent
+func (ctrl *Controller) State(name string) (*RemoteState, bool) {
+	rc, exists := ctrl.config.Snapshot(name)
+	if !exists {
+		return nil, false
+	}
+
+	state := &RemoteState{Control: rc}
+
+	ctrl.posMu.Lock()
+	defer ctrl.posMu.Unlock()
+
+	if m, moving := ctrl.motions[name]; moving {
+		pos := roundPosition(m.position(time.Now()))
+		state.Position = &pos
+		state.Moving = m.direction()
+	}
+	return state, true
+}
+
+// trackCommand met à jour l'estimation de position après l'émission d'une commande
+func (ctrl *Controller) trackCommand(name string, command byte, repeats int, at time.Time) {
+	rc, exists := ctrl.config.Snapshot(name)
+	if !exists || !rc.TracksPosition() {
+		return
+	}
+
+	ctrl.posMu.Lock()
+	defer ctrl.posMu.Unlock()
+
+	// Position courante : déplacement en cours, sinon dernière position connue
+	var current *float64
+	m, moving := ctrl.motions[name]
+	if moving {
+		m.timer.Stop()
+		delete(ctrl.motions, name)
+		pos := m.position(at)
+		current = &pos
+	} else if rc.Position != nil {
+		pos := float64(*rc.Position)
+		current = &pos
+	}
+
+	switch command {
+	case remote.CmdUp:
+		ctrl.startMotion(name, &rc, current, PositionOpen, at)
+	case remote.CmdDown:
+		ctrl.startMotion(name, &rc, current, PositionClosed, at)
+	case remote.CmdMy:
+		switch {
+		case moving:
+			// Arrêt du volet
+			ctrl.storePosition(name, current, false)
+		case repeats >= remote.RepeatsFor(myStoreHold):
+			// Appui long à l'arrêt : enregistrement de la position favorite
+			ctrl.storePosition(name, current, true)
+		case rc.MyPosition != nil:
+			ctrl.startMotion(name, &rc, current, float64(*rc.MyPosition), at)
+		default:
+			// Position favorite inconnue : l'estimation est perdue
+			ctrl.storePosition(name, nil, false)
+		}
+	}
+}
+
+// startMotion démarre un déplacement vers target ; l'appelant détient posMu
+func (ctrl *Controller) startMotion(name string, rc *remote.Control, current *float64, target float64, at time.Time) {
+	// Position inconnue : on suppose le volet à l'opposé pour couvrir toute la course
+	from := PositionClosed
+	if target <= PositionClosed {
+		from = PositionOpen
+	}
+	fromPos := float64(from)
+	if current != nil {
+		fromPos = *current
+	}
+
+	travel := time.Duration(rc.TravelUpMs) * time.Millisecond
+	if target < fromPos {
+		travel = time.Duration(rc.TravelDownMs) * time.Millisecond
+	}
+
+	m := &motion{
+		from:     fromPos,
+		to:       target,
+		start:    at,
+		duration: time.Duration(math.Abs(target-fromPos) / 100 * float64(travel)),
+	}
+	m.timer = time.AfterFunc(time.Until(at.Add(m.duration)), func() {
+		ctrl.finishMotion(name, m)
+	})
+	ctrl.motions[name] = m
+
+	// La cible est persistée dès le départ : c'est la meilleure estimation
+	// si le processus s'arrête avant la fin du déplacement (mode CLI)
+	ctrl.storePosition(name, &target, false)
+}
+
+// finishMotion enregistre la position atteinte à la fin d'un déplacement
+func (ctrl *Controller) finishMotion(name string, m *motion) {
+	ctrl.posMu.Lock()
+	defer ctrl.posMu.Unlock()
+
+	// Le déplacement a pu être interrompu par une autre commande
+	if ctrl.motions[name] != m {
+		return
+	}
+	delete(ctrl.motions, name)
+
+	target := m.to
+	ctrl.storePosition(name, &target, false)
+}
+
+// storePosition enregistre la position estimée (nil = inconnue) ou la position
+// favorite, écrite sur le disque avec la prochaine sauvegarde ; l'appelant
+// détient posMu
+func (ctrl *Controller) storePosition(name string, pos *float64, favourite bool) {
+	err := ctrl.config.UpdateRemoteDeferred(name, func(rc *remote.Control) {
+		var value *int
+		if pos != nil {
+			p := roundPosition(*pos)
+			value = &p
+		}
+		if favourite {
+			if value != nil {
+				rc.MyPosition = value
+			}
+			return
+		}
+		rc.Position = value
+	})
+	if err != nil {
+		log.Printf("Warning: failed to save position of '%s': %v", name, err)
+	}
+	if favourite {
+		return
+	}
+
+	// Position à l'instant présent : au départ d'un déplacement, la position
+	// persistée est déjà la cible
+	ev := Event{Type: EventState, Remote: name}
+	if m, moving := ctrl.motions[name]; moving {
+		p := roundPosition(m.position(time.Now()))
+		ev.Position = &p
+		ev.Moving = m.direction()
+	} else if pos != nil {
+		p := roundPosition(*pos)
+		ev.Position = &p
+	}
+	ctrl.publish(ev)
+}
+
+// roundPosition arrondit une position au pourcent le plus proche
+func roundPosition(pos float64) int {
+	return int(math.Round(math.Max(PositionClosed, math.Min(PositionOpen, pos))))
+}
@@ -0,0 +1,462 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	"rtscommander/m/internal/controller"
+	"rtscommander/m/internal/remote"
+)
+
+// Valeurs par défaut des options
+const (
+	DefaultTopic           = "rtscommander"
+	DefaultDiscoveryPrefix = "homeassistant"
+)
+
+// Charges utiles échangées avec Home Assistant
+const (
+	payloadOpen    = "OPEN"
+	payloadClose   = "CLOSE"
+	payloadStop    = "STOP"
+	payloadOnline  = "online"
+	payloadOffline = "offline"
+)
+
+// États d'un volet (cover) Home Assistant
+const (
+	stateOpen    = "open"
+	stateClosed  = "closed"
+	stateOpening = "opening"
+	stateClosing = "closing"
+	stateStopped = "stopped"
+)
+
+// Délai maximal d'attente des opérations MQTT
+const operationTimeout = 10 * time.Second
+
+// Options décrit la connexion au broker et les topics utilisés
+type Options struct {
+	Broker          string // URL du broker, ex. "tcp://localhost:1883"
+	ClientID        string
+	Username        string
+	Password        string
+	Topic           string   // Préfixe des topics d'état et de commande
+	DiscoveryPrefix string   // Préfixe de découverte Home Assistant
+	Remotes         []string // Télécommandes exposées et pilotables ; toutes si vide
+}
+
+// Bridge expose les télécommandes comme volets Home Assistant via MQTT
+type Bridge struct {
+	ctrl      *controller.Controller
+	opts      Options
+	client    paho.Client
+	principal *controller.Principal
+	session   *controller.Session // Commandes soumises à la liste d'accès du pont
+
+	unsubscribe func()
+	done        chan struct{}
+
+	mu       sync.Mutex
+	ids      map[string]string // Identifiant de topic -> nom de la télécommande
+	topics   map[string]string // Nom de la télécommande -> identifiant de topic
+	entities map[string]string // Nom de la télécommande -> unique_id publié
+}
+
+// New crée un pont MQTT pour le contrôleur
+func New(ctrl *controller.Controller, opts Options) *Bridge {
+	if opts.Topic == "" {
+		opts.Topic = DefaultTopic
+	}
+	if opts.DiscoveryPrefix == "" {
+		opts.DiscoveryPrefix = DefaultDiscoveryPrefix
+	}
+	if opts.ClientID == "" {
+		opts.ClientID = opts.Topic
+	}
+
+	principal := &controller.Principal{Source: controller.SourceMQTT, Name: "mqtt", Remotes: opts.Remotes}
+	return &Bridge{
+		ctrl:      ctrl,
+		opts:      opts,
+		principal: principal,
+		session:   ctrl.As(principal),
+		ids:       make(map[string]string),
+		topics:    make(map[string]string),
+		entities:  make(map[string]string),
+	}
+}
+
+// Start se connecte au broker, publie la découverte Home Assistant et relaie
+// ensuite commandes et états. La reconnexion est automatique.
+func (b *Bridge) Start() error {
+	clientOpts := paho.NewClientOptions().
+		AddBroker(b.opts.Broker).
+		SetClientID(b.opts.ClientID).
+		SetUsername(b.opts.Username).
+		SetPassword(b.opts.Password).
+		SetAutoReconnect(true).
+		SetWill(b.availabilityTopic(), payloadOffline, 1, true).
+		SetOnConnectHandler(b.onConnect).
+		SetConnectionLostHandler(func(_ paho.Client, err error) {
+			log.Printf("[mqtt] Connection lost: %v", err)
+		})
+
+	b.client = paho.NewClient(clientOpts)
+	token := b.client.Connect()
+	if !token.WaitTimeout(operationTimeout) {
+		return fmt.Errorf("timeout connecting to MQTT broker %s", b.opts.Broker)
+	}
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("failed to connect to MQTT broker %s: %v", b.opts.Broker, err)
+	}
+
+	events, unsubscribe := b.ctrl.Subscribe()
+	b.unsubscribe = unsubscribe
+	b.done = make(chan struct{})
+	go b.forward(events)
+
+	return nil
+}
+
+// Stop publie l'indisponibilité et se déconnecte du broker. Une déconnexion
+// propre ne déclenche pas le message de dernière volonté : l'indisponibilité
+// est publiée et acquittée avant.
+func (b *Bridge) Stop() {
+	b.unsubscribe()
+	<-b.done
+
+	token := b.client.Publish(b.availabilityTopic(), 1, true, payloadOffline)
+	if token.WaitTimeout(operationTimeout) && token.Error() != nil {
+		log.Printf("[mqtt] Failed to publish to %s: %v", b.availabilityTopic(), token.Error())
+	}
+	b.client.Disconnect(250)
+}
+
+// onConnect (re)publie la découverte et les états, et s'abonne aux commandes
+func (b *Bridge) onConnect(client paho.Client) {
+	log.Printf("[mqtt] Connected to %s", b.opts.Broker)
+
+	subscriptions := map[string]byte{
+		b.opts.Topic + "/+/set":          1,
+		b.opts.Topic + "/+/set_position": 1,
+		// Redémarrage de Home Assistant : la découverte doit être republiée
+		b.opts.DiscoveryPrefix + "/status": 1,
+	}
+	token := client.SubscribeMultiple(subscriptions, b.handleMessage)
+	if token.WaitTimeout(operationTimeout) && token.Error() != nil {
+		log.Printf("[mqtt] Failed to subscribe: %v", token.Error())
+	}
+
+	b.announce()
+}
+
+// announce publie la découverte et l'état de toutes les télécommandes exposées,
+// dans l'ordre alphabétique : les topics en collision sont attribués de la même
+// façon à chaque démarrage
+func (b *Bridge) announce() {
+	b.publish(b.availabilityTopic(), payloadOnline, true)
+	names := b.ctrl.Config().ListRemotes()
+	sort.Strings(names)
+	for _, name := range names {
+		if !b.principal.Allows(name) {
+			continue
+		}
+		b.publishDiscovery(name)
+		b.publishState(name)
+	}
+}
+
+// forward publie l'état des télécommandes à chaque événement du contrôleur
+func (b *Bridge) forward(events <-chan controller.Event) {
+	defer close(b.done)
+
+	for ev := range events {
+		if ev.Remote == "" || !b.principal.Allows(ev.Remote) {
+			continue
+		}
+		switch ev.Type {
+		case controller.EventState:
+			b.publishState(ev.Remote)
+		case controller.EventRemoteAdded:
+			b.publishDiscovery(ev.Remote)
+			b.publishState(ev.Remote)
+		case controller.EventRemoteRemoved:
+			b.removeDiscovery(ev.Remote)
+		}
+	}
+}
+
+// handleMessage traite une commande reçue de Home Assistant
+func (b *Bridge) handleMessage(_ paho.Client, msg paho.Message) {
+	payload := strings.TrimSpace(string(msg.Payload()))
+
+	if msg.Topic() == b.opts.DiscoveryPrefix+"/status" {
+		if payload == payloadOnline {
+			b.announce()
+		}
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(msg.Topic(), b.opts.Topic+"/"), "/")
+	if len(parts) != 2 {
+		return
+	}
+	name, ok := b.remoteName(parts[0])
+	if !ok {
+		log.Printf("[mqtt] Unknown remote in topic %s", msg.Topic())
+		return
+	}
+
+	// Exécution hors du callback : un déplacement ou un appui long ne doit pas
+	// bloquer la réception des messages
+	go func() {
+		if err := b.execute(name, parts[1], payload); err != nil {
+			log.Printf("[mqtt] Command '%s' on '%s' failed: %v", payload, name, err)
+		}
+	}()
+}
+
+// execute envoie la commande correspondant à un message
+func (b *Bridge) execute(name, action, payload string) error {
+	if action == "set_position" {
+		position, err := strconv.Atoi(payload)
+		if err != nil {
+			return fmt.Errorf("invalid position '%s'", payload)
+		}
+		_, err = b.session.StartPosition(name, position)
+		return err
+	}
+
+	// OPEN / CLOSE / STOP, ou le nom d'une commande RTS
+	command := strings.ToLower(payload)
+	switch payload {
+	case payloadOpen:
+		command = "up"
+	case payloadClose:
+		command = "down"
+	case payloadStop:
+		command = "my"
+	}
+
+	cmd, ok := remote.ParseCommand(command)
+	if !ok {
+		return fmt.Errorf("unknown command")
+	}
+	return b.session.Send(name, cmd)
+}
+
+// discovery représente la configuration de découverte d'un volet Home Assistant
+type discovery struct {
+	Name              string          `json:"name"`
+	UniqueID          string          `json:"unique_id"`
+	ObjectID          string          `json:"object_id"`
+	DeviceClass       string          `json:"device_class"`
+	CommandTopic      string          `json:"command_topic"`
+	PayloadOpen       string          `json:"payload_open"`
+	PayloadClose      string          `json:"payload_close"`
+	PayloadStop       string          `json:"payload_stop"`
+	StateTopic        string          `json:"state_topic,omitempty"`
+	PositionTopic     string          `json:"position_topic,omitempty"`
+	SetPositionTopic  string          `json:"set_position_topic,omitempty"`
+	AvailabilityTopic string          `json:"availability_topic"`
+	Optimistic        bool            `json:"optimistic"`
+	Device            discoveryDevice `json:"device"`
+}
+
+// discoveryDevice décrit l'appareil rattaché à l'entité
+type discoveryDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+	Model        string   `json:"model"`
+}
+
+// publishDiscovery publie la configuration de découverte d'une télécommande
+func (b *Bridge) publishDiscovery(name string) {
+	rc, exists := b.ctrl.Config().Snapshot(name)
+	if !exists {
+		return
+	}
+
+	id := b.register(name, rc.Address)
+	uniqueID := fmt.Sprintf("%s_%06x", b.opts.Topic, rc.Address)
+	b.mu.Lock()
+	previous, published := b.entities[name]
+	b.entities[name] = uniqueID
+	b.mu.Unlock()
+
+	// Adresse modifiée : l'entité publiée sous l'ancien unique_id est retirée,
+	// sinon elle resterait orpheline dans Home Assistant
+	if published && previous != uniqueID {
+		b.publish(b.discoveryTopic(previous), "", true)
+	}
+	config := discovery{
+		Name:              name,
+		UniqueID:          uniqueID,
+		ObjectID:          id,
+		DeviceClass:       "shutter",
+		CommandTopic:      b.remoteTopic(id, "set"),
+		PayloadOpen:       payloadOpen,
+		PayloadClose:      payloadClose,
+		PayloadStop:       payloadStop,
+		AvailabilityTopic: b.availabilityTopic(),
+		// Sans temps de course, l'état n'est pas connu : Home Assistant suppose
+		// que les commandes aboutissent
+		Optimistic: !rc.TracksPosition(),
+		Device: discoveryDevice{
+			Identifiers:  []string{uniqueID},
+			Name:         name,
+			Manufacturer: "Somfy",
+			Model:        "RTS",
+		},
+	}
+	if rc.TracksPosition() {
+		config.StateTopic = b.remoteTopic(id, "state")
+		config.PositionTopic = b.remoteTopic(id, "position")
+		config.SetPositionTopic = b.remoteTopic(id, "set_position")
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return
+	}
+	b.publish(b.discoveryTopic(uniqueID), string(data), true)
+}
+
+// removeDiscovery retire de Home Assistant le volet d'une télécommande supprimée
+func (b *Bridge) removeDiscovery(name string) {
+	b.mu.Lock()
+	id := b.topics[name]
+	uniqueID, published := b.entities[name]
+	delete(b.entities, name)
+	delete(b.topics, name)
+	delete(b.ids, id)
+	b.mu.Unlock()
+
+	if !published {
+		return
+	}
+	// Une configuration vide retenue supprime l'entité
+	b.publish(b.discoveryTopic(uniqueID), "", true)
+	b.publish(b.remoteTopic(id, "state"), "", true)
+	b.publish(b.remoteTopic(id, "position"), "", true)
+}
+
+// publishState publie l'état et la position estimés d'une télécommande
+func (b *Bridge) publishState(name string) {
+	state, exists := b.ctrl.State(name)
+	if !exists || !state.TracksPosition() {
+		return
+	}
+	id := b.register(name, state.Address)
+
+	if state.Position == nil {
+		return
+	}
+	b.publish(b.remoteTopic(id, "state"), coverState(state), true)
+	b.publish(b.remoteTopic(id, "position"), strconv.Itoa(*state.Position), true)
+}
+
+// coverState convertit l'état estimé d'un volet en état Home Assistant
+func coverState(state *controller.RemoteState) string {
+	switch {
+	case state.Moving == "up":
+		return stateOpening
+	case state.Moving == "down":
+		return stateClosing
+	case *state.Position >= controller.PositionOpen:
+		return stateOpen
+	case *state.Position <= controller.PositionClosed:
+		return stateClosed
+	}
+	return stateStopped
+}
+
+// publish publie un message (QoS 1) sans attendre l'acquittement
+func (b *Bridge) publish(topic, payload string, retained bool) {
+	token := b.client.Publish(topic, 1, retained, payload)
+	go func() {
+		if token.WaitTimeout(operationTimeout) && token.Error() != nil {
+			log.Printf("[mqtt] Failed to publish to %s: %v", topic, token.Error())
+		}
+	}()
+}
+
+// register associe une télécommande à son identifiant de topic. Deux noms
+// peuvent donner le même identifiant ("Salon" et "salon", "a b" et "a_b") :
+// la télécommande enregistrée en second reçoit son adresse en suffixe, pour que
+// ses commandes ne pilotent pas l'autre.
+func (b *Bridge) register(name string, address uint32) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if id, registered := b.topics[name]; registered {
+		return id
+	}
+
+	base := topicID(name)
+	id := base
+	for n := 1; ; n++ {
+		other, taken := b.ids[id]
+		if !taken {
+			break
+		}
+		if n == 1 {
+			log.Printf("[mqtt] Warning: topic of '%s' collides with '%s', using %s_%06x", name, other, base, address)
+			id = fmt.Sprintf("%s_%06x", base, address)
+		} else {
+			id = fmt.Sprintf("%s_%06x_%d", base, address, n)
+		}
+	}
+
+	b.ids[id] = name
+	b.topics[name] = id
+	return id
+}
+
+// remoteName retrouve la télécommande associée à un identifiant de topic
+func (b *Bridge) remoteName(id string) (string, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	name, ok := b.ids[id]
+	return name, ok
+}
+
+// remoteTopic retourne un topic propre à une télécommande
+func (b *Bridge) remoteTopic(id, suffix string) string {
+	return b.opts.Topic + "/" + id + "/" + suffix
+}
+
+// discoveryTopic retourne le topic de découverte d'un volet
+func (b *Bridge) discoveryTopic(uniqueID string) string {
+	return fmt.Sprintf("%s/cover/%s/config", b.opts.DiscoveryPrefix, uniqueID)
+}
+
+// availabilityTopic retourne le topic de disponibilité du pont
+func (b *Bridge) availabilityTopic() string {
+	return b.opts.Topic + "/status"
+}
+
+// topicID convertit un nom de télécommande en identifiant utilisable dans un
+// topic MQTT et comme object_id Home Assistant
+func topicID(name string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune('_')
+		}
+	}
+	return sb.String()
+}
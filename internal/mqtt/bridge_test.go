@@ -0,0 +1,276 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"rtscommander/m/internal/config"
+	"rtscommander/m/internal/controller"
+	"rtscommander/m/internal/radio"
+	"rtscommander/m/internal/remote"
+)
+
+// testSetup réunit le broker, le simulateur et le pont d'un test
+type testSetup struct {
+	broker *testBroker
+	sim    *radio.Simulator
+	ctrl   *controller.Controller
+	bridge *Bridge
+}
+
+// newTestSetup démarre un pont connecté au broker de test, pour les
+// télécommandes données
+func newTestSetup(t *testing.T, remotes map[string]*remote.Control, opts Options) *testSetup {
+	t.Helper()
+	cfg, err := config.Load(filepath.Join(t.TempDir(), "remotes.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cfg.Close() })
+	for name, rc := range remotes {
+		if err := cfg.AddRemote(name, rc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := &testSetup{broker: startBroker(t), sim: radio.NewSimulator(0, nil)}
+	s.ctrl = controller.New(cfg, s.sim)
+	opts.Broker = s.broker.URL()
+	s.bridge = New(s.ctrl, opts)
+	if err := s.bridge.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if s.bridge != nil {
+			s.bridge.Stop()
+		}
+	})
+	return s
+}
+
+// waitFrames attend que le simulateur ait émis count trames valides pour
+// l'adresse donnée et retourne leurs commandes, sans doublons consécutifs
+// (répétitions d'un même appui)
+func (s *testSetup) waitFrames(t *testing.T, address uint32, count int) []byte {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var commands []byte
+		last := -1
+		for _, rec := range s.sim.Records() {
+			if !rec.Valid || rec.Address != address || int(rec.RollingCode) == last {
+				continue
+			}
+			last = int(rec.RollingCode)
+			commands = append(commands, rec.Command)
+		}
+		if len(commands) >= count {
+			return commands
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("address 0x%06X: got commands %v, want %d", address, commands, count)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+// discoveryOf attend et décode la configuration de découverte d'une adresse
+func (s *testSetup) discoveryOf(t *testing.T, address uint32) discovery {
+	t.Helper()
+	topic := fmt.Sprintf("homeassistant/cover/rtscommander_%06x/config", address)
+	payload := s.broker.waitRetained(topic, func(string) bool { return true })
+
+	var d discovery
+	if err := json.Unmarshal([]byte(payload), &d); err != nil {
+		t.Fatalf("%s: %v", topic, err)
+	}
+	return d
+}
+
+func TestBridgeDiscovery(t *testing.T) {
+	s := newTestSetup(t, map[string]*remote.Control{
+		"Salon Est": {Address: 0x123456, TravelUpMs: 20000, TravelDownMs: 18000, Position: intPtr(100)},
+		"cuisine":   {Address: 0xABCDEF},
+		"garage":    {Address: 0x000001},
+	}, Options{Remotes: []string{"Salon Est", "cuisine"}})
+
+	s.broker.waitRetained("rtscommander/status", func(p string) bool { return p == payloadOnline })
+
+	salon := s.discoveryOf(t, 0x123456)
+	want := discovery{
+		Name:              "Salon Est",
+		UniqueID:          "rtscommander_123456",
+		ObjectID:          "salon_est",
+		DeviceClass:       "shutter",
+		CommandTopic:      "rtscommander/salon_est/set",
+		PayloadOpen:       payloadOpen,
+		PayloadClose:      payloadClose,
+		PayloadStop:       payloadStop,
+		StateTopic:        "rtscommander/salon_est/state",
+		PositionTopic:     "rtscommander/salon_est/position",
+		SetPositionTopic:  "rtscommander/salon_est/set_position",
+		AvailabilityTopic: "rtscommander/status",
+		Device: discoveryDevice{
+			Identifiers:  []string{"rtscommander_123456"},
+			Name:         "Salon Est",
+			Manufacturer: "Somfy",
+			Model:        "RTS",
+		},
+	}
+	if fmt.Sprint(salon) != fmt.Sprint(want) {
+		t.Errorf("discovery of 'Salon Est':\n got %+v\nwant %+v", salon, want)
+	}
+	s.broker.waitRetained("rtscommander/salon_est/state", func(p string) bool { return p == stateOpen })
+	s.broker.waitRetained("rtscommander/salon_est/position", func(p string) bool { return p == "100" })
+
+	// Sans temps de course : mode optimiste, ni état ni position
+	cuisine := s.discoveryOf(t, 0xABCDEF)
+	if !cuisine.Optimistic || cuisine.StateTopic != "" || cuisine.SetPositionTopic != "" {
+		t.Errorf("discovery of 'cuisine' = %+v, want optimistic without state topics", cuisine)
+	}
+
+	// Hors de la liste --mqtt-remotes : ni découverte ni commande
+	if _, ok := s.broker.Retained("homeassistant/cover/rtscommander_000001/config"); ok {
+		t.Errorf("'garage' discovered although not exposed")
+	}
+	s.broker.Publish("rtscommander/garage/set", payloadOpen, false)
+	s.broker.Publish("rtscommander/cuisine/set", payloadOpen, false)
+	s.waitFrames(t, 0xABCDEF, 1)
+	for _, rec := range s.sim.Records() {
+		if rec.Address == 0x000001 {
+			t.Fatalf("command sent to 'garage'")
+		}
+	}
+}
+
+func TestBridgeSetCommands(t *testing.T) {
+	s := newTestSetup(t, map[string]*remote.Control{
+		"cuisine": {Address: 0xABCDEF},
+	}, Options{})
+	s.discoveryOf(t, 0xABCDEF)
+
+	tests := []struct {
+		payload string
+		command byte
+	}{
+		{payloadOpen, remote.CmdUp},
+		{payloadClose, remote.CmdDown},
+		{payloadStop, remote.CmdMy},
+		{"prog", remote.CmdProg},
+	}
+	for i, tt := range tests {
+		s.broker.Publish("rtscommander/cuisine/set", tt.payload, false)
+		commands := s.waitFrames(t, 0xABCDEF, i+1)
+		if got := commands[i]; got != tt.command {
+			t.Errorf("%s: sent command 0x%X, want 0x%X", tt.payload, got, tt.command)
+		}
+	}
+
+	// Commande inconnue : rien n'est émis
+	s.broker.Publish("rtscommander/cuisine/set", "sideways", false)
+	time.Sleep(100 * time.Millisecond)
+	if commands := s.waitFrames(t, 0xABCDEF, len(tests)); len(commands) != len(tests) {
+		t.Errorf("unknown command sent: %v", commands)
+	}
+}
+
+func TestBridgeSetPosition(t *testing.T) {
+	s := newTestSetup(t, map[string]*remote.Control{
+		"salon": {Address: 0x123456, TravelUpMs: 2000, TravelDownMs: 2000, Position: intPtr(100)},
+	}, Options{})
+	s.discoveryOf(t, 0x123456)
+
+	s.broker.Publish("rtscommander/salon/set_position", "50", false)
+
+	// Descente pendant la moitié de la course, puis arrêt sur My
+	commands := s.waitFrames(t, 0x123456, 2)
+	if commands[0] != remote.CmdDown || commands[1] != remote.CmdMy {
+		t.Errorf("commands = %v, want [DOWN MY]", commands)
+	}
+	// Position estimée à l'émission de My : à quelques pourcents près
+	s.broker.waitRetained("rtscommander/salon/position", func(p string) bool {
+		position, err := strconv.Atoi(p)
+		return err == nil && position >= 47 && position <= 53
+	})
+	s.broker.waitRetained("rtscommander/salon/state", func(p string) bool { return p == stateStopped })
+
+	// Fin de course : pas de My
+	s.broker.Publish("rtscommander/salon/set_position", "0", false)
+	commands = s.waitFrames(t, 0x123456, 3)
+	if commands[2] != remote.CmdDown {
+		t.Errorf("commands = %v, want DOWN last", commands)
+	}
+	s.broker.waitRetained("rtscommander/salon/state", func(p string) bool { return p == stateClosed })
+}
+
+func TestBridgeTopicCollision(t *testing.T) {
+	s := newTestSetup(t, map[string]*remote.Control{
+		"Salon": {Address: 0x111111},
+		"salon": {Address: 0x222222},
+	}, Options{})
+
+	// "Salon" précède "salon" dans l'ordre alphabétique et garde le topic simple
+	first := s.discoveryOf(t, 0x111111)
+	second := s.discoveryOf(t, 0x222222)
+	if first.CommandTopic != "rtscommander/salon/set" {
+		t.Errorf("'Salon' command topic = %s", first.CommandTopic)
+	}
+	if second.CommandTopic != "rtscommander/salon_222222/set" {
+		t.Errorf("'salon' command topic = %s", second.CommandTopic)
+	}
+
+	s.broker.Publish(second.CommandTopic, payloadOpen, false)
+	s.waitFrames(t, 0x222222, 1)
+	time.Sleep(100 * time.Millisecond)
+	for _, rec := range s.sim.Records() {
+		if rec.Address == 0x111111 {
+			t.Fatalf("command on %s drove 'Salon'", second.CommandTopic)
+		}
+	}
+}
+
+func TestBridgeStopPublishesOffline(t *testing.T) {
+	s := newTestSetup(t, map[string]*remote.Control{"cuisine": {Address: 0xABCDEF}}, Options{})
+	s.broker.waitRetained("rtscommander/status", func(p string) bool { return p == payloadOnline })
+
+	s.bridge.Stop()
+	s.bridge = nil
+	if payload, _ := s.broker.Retained("rtscommander/status"); payload != payloadOffline {
+		t.Errorf("status after Stop = %q, want %q", payload, payloadOffline)
+	}
+}
+
+func TestBridgeAddressChangeReplacesEntity(t *testing.T) {
+	s := newTestSetup(t, map[string]*remote.Control{
+		"salon": {Address: 0x123456},
+	}, Options{})
+	s.discoveryOf(t, 0x123456)
+
+	if err := s.ctrl.UpdateRemote("salon", func(rc *remote.Control) { rc.Address = 0x654321 }); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nouvelle entité publiée, l'ancienne configuration retenue effacée
+	if d := s.discoveryOf(t, 0x654321); d.Name != "salon" || d.CommandTopic != "rtscommander/salon/set" {
+		t.Errorf("discovery after address change = %+v", d)
+	}
+	old := "homeassistant/cover/rtscommander_123456/config"
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, ok := s.broker.Retained(old); !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s still retained", old)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
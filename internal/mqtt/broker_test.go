@@ -0,0 +1,314 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Types de paquets MQTT 3.1.1 traités par le broker de test
+const (
+	packetConnect     = 1
+	packetConnack     = 2
+	packetPublish     = 3
+	packetPuback      = 4
+	packetSubscribe   = 8
+	packetSuback      = 9
+	packetUnsubscribe = 10
+	packetUnsuback    = 11
+	packetPingreq     = 12
+	packetPingresp    = 13
+	packetDisconnect  = 14
+)
+
+// testBroker est un broker MQTT 3.1.1 minimal exécuté dans le processus de
+// test : abonnements avec jokers, messages retenus et dernière volonté. Les
+// messages sont distribués en QoS 0.
+type testBroker struct {
+	t  *testing.T
+	ln net.Listener
+
+	mu       sync.Mutex
+	clients  map[*brokerClient]bool
+	retained map[string]string
+}
+
+// brokerClient est une connexion au broker de test
+type brokerClient struct {
+	conn net.Conn
+	wmu  sync.Mutex
+
+	subscriptions []string
+	will          *brokerMessage
+}
+
+// brokerMessage est un message publié
+type brokerMessage struct {
+	topic   string
+	payload string
+	retain  bool
+}
+
+// startBroker démarre un broker de test sur un port local libre
+func startBroker(t *testing.T) *testBroker {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &testBroker{t: t, ln: ln, clients: make(map[*brokerClient]bool), retained: make(map[string]string)}
+	go b.serve()
+	t.Cleanup(b.close)
+	return b
+}
+
+// URL retourne l'adresse du broker pour paho
+func (b *testBroker) URL() string {
+	return "tcp://" + b.ln.Addr().String()
+}
+
+func (b *testBroker) close() {
+	b.ln.Close()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for c := range b.clients {
+		c.conn.Close()
+	}
+}
+
+func (b *testBroker) serve() {
+	for {
+		conn, err := b.ln.Accept()
+		if err != nil {
+			return
+		}
+		go b.handle(&brokerClient{conn: conn})
+	}
+}
+
+// handle traite les paquets d'un client jusqu'à sa déconnexion ; une
+// connexion perdue sans DISCONNECT publie sa dernière volonté
+func (b *testBroker) handle(c *brokerClient) {
+	r := bufio.NewReader(c.conn)
+	clean := false
+	defer func() {
+		c.conn.Close()
+		b.mu.Lock()
+		delete(b.clients, c)
+		b.mu.Unlock()
+		if !clean && c.will != nil {
+			b.Publish(c.will.topic, c.will.payload, c.will.retain)
+		}
+	}()
+
+	for {
+		header, body, err := readPacket(r)
+		if err != nil {
+			return
+		}
+		switch header >> 4 {
+		case packetConnect:
+			c.will = parseConnect(body)
+			b.mu.Lock()
+			b.clients[c] = true
+			b.mu.Unlock()
+			c.write(packetConnack<<4, []byte{0, 0})
+		case packetPublish:
+			qos := (header >> 1) & 3
+			topic, rest := readString(body)
+			if qos > 0 {
+				c.write(packetPuback<<4, rest[:2])
+				rest = rest[2:]
+			}
+			b.Publish(topic, string(rest), header&1 == 1)
+		case packetSubscribe:
+			id, rest := body[:2], body[2:]
+			var topics []string
+			var granted []byte
+			for len(rest) > 0 {
+				var topic string
+				topic, rest = readString(rest)
+				rest = rest[1:] // QoS demandée, distribution en QoS 0
+				topics = append(topics, topic)
+				granted = append(granted, 0)
+			}
+			b.mu.Lock()
+			c.subscriptions = append(c.subscriptions, topics...)
+			var retained []brokerMessage
+			for topic, payload := range b.retained {
+				for _, filter := range topics {
+					if topicMatches(filter, topic) {
+						retained = append(retained, brokerMessage{topic, payload, true})
+						break
+					}
+				}
+			}
+			b.mu.Unlock()
+			c.write(packetSuback<<4, append(append([]byte(nil), id...), granted...))
+			for _, msg := range retained {
+				c.deliver(msg)
+			}
+		case packetUnsubscribe:
+			c.write(packetUnsuback<<4, body[:2])
+		case packetPingreq:
+			c.write(packetPingresp<<4, nil)
+		case packetDisconnect:
+			clean = true
+			return
+		}
+	}
+}
+
+// Publish distribue un message aux abonnés et met à jour les messages
+// retenus ; une charge vide retenue efface le message retenu
+func (b *testBroker) Publish(topic, payload string, retain bool) {
+	b.mu.Lock()
+	if retain {
+		if payload == "" {
+			delete(b.retained, topic)
+		} else {
+			b.retained[topic] = payload
+		}
+	}
+	var targets []*brokerClient
+	for c := range b.clients {
+		for _, filter := range c.subscriptions {
+			if topicMatches(filter, topic) {
+				targets = append(targets, c)
+				break
+			}
+		}
+	}
+	b.mu.Unlock()
+
+	for _, c := range targets {
+		c.deliver(brokerMessage{topic: topic, payload: payload})
+	}
+}
+
+// Retained retourne le message retenu d'un topic
+func (b *testBroker) Retained(topic string) (string, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	payload, ok := b.retained[topic]
+	return payload, ok
+}
+
+// waitRetained attend que le message retenu d'un topic vérifie match
+func (b *testBroker) waitRetained(topic string, match func(payload string) bool) string {
+	b.t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		payload, ok := b.Retained(topic)
+		if ok && match(payload) {
+			return payload
+		}
+		if time.Now().After(deadline) {
+			b.t.Fatalf("retained message on %s: got %q (present: %v)", topic, payload, ok)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// deliver envoie un message PUBLISH en QoS 0
+func (c *brokerClient) deliver(msg brokerMessage) {
+	var flags byte
+	if msg.retain {
+		flags = 1
+	}
+	body := appendString(nil, msg.topic)
+	c.write(packetPublish<<4|flags, append(body, msg.payload...))
+}
+
+// write envoie un paquet au client
+func (c *brokerClient) write(header byte, body []byte) {
+	packet := []byte{header}
+	length := len(body)
+	for {
+		digit := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			digit |= 0x80
+		}
+		packet = append(packet, digit)
+		if length == 0 {
+			break
+		}
+	}
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	c.conn.Write(append(packet, body...))
+}
+
+// readPacket lit l'en-tête fixe et le contenu d'un paquet
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		digit, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		if i == 4 {
+			return 0, nil, errors.New("malformed remaining length")
+		}
+		length += int(digit&0x7F) * multiplier
+		multiplier *= 128
+		if digit&0x80 == 0 {
+			break
+		}
+	}
+	body := make([]byte, length)
+	_, err = io.ReadFull(r, body)
+	return header, body, err
+}
+
+// parseConnect retourne la dernière volonté d'un paquet CONNECT
+func parseConnect(body []byte) *brokerMessage {
+	_, rest := readString(body) // Nom du protocole
+	flags := rest[1]
+	rest = rest[4:] // Niveau, drapeaux, keep alive
+	_, rest = readString(rest)
+	if flags&0x04 == 0 {
+		return nil
+	}
+	topic, rest := readString(rest)
+	payload, _ := readString(rest)
+	return &brokerMessage{topic: topic, payload: payload, retain: flags&0x20 != 0}
+}
+
+// readString lit une chaîne préfixée par sa longueur
+func readString(data []byte) (string, []byte) {
+	n := int(binary.BigEndian.Uint16(data))
+	return string(data[2 : 2+n]), data[2+n:]
+}
+
+// appendString ajoute une chaîne préfixée par sa longueur
+func appendString(data []byte, s string) []byte {
+	data = binary.BigEndian.AppendUint16(data, uint16(len(s)))
+	return append(data, s...)
+}
+
+// topicMatches indique si un topic correspond à un filtre d'abonnement
+func topicMatches(filter, topic string) bool {
+	filterLevels := strings.Split(filter, "/")
+	topicLevels := strings.Split(topic, "/")
+	for i, level := range filterLevels {
+		if level == "#" {
+			return true
+		}
+		if i >= len(topicLevels) || (level != "+" && level != topicLevels[i]) {
+			return false
+		}
+	}
+	return len(filterLevels) == len(topicLevels)
+}
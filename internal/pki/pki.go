@@ -0,0 +1,194 @@
+// Package pki génère une autorité de certification locale et les certificats
+// serveur et client nécessaires au TLS (et au TLS mutuel) de l'API.
+package pki
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Fichiers de l'autorité et du certificat serveur dans le répertoire de la PKI
+const (
+	CAFile        = "ca.pem"
+	CAKeyFile     = "ca-key.pem"
+	ServerFile    = "server.pem"
+	ServerKeyFile = "server-key.pem"
+)
+
+// Durées de validité
+const (
+	caValidity   = 10 * 365 * 24 * time.Hour
+	certValidity = 825 * 24 * time.Hour // Maximum accepté par les navigateurs
+)
+
+// Organisation inscrite dans les certificats générés
+const organization = "rtsCommander"
+
+// InitCA crée l'autorité dans dir si elle n'existe pas encore, puis (re)génère
+// le certificat serveur valable pour hosts (noms DNS ou adresses IP)
+func InitCA(dir string, hosts []string) error {
+	if len(hosts) == 0 {
+		return fmt.Errorf("at least one host is required for the server certificate")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, CAFile)); errors.Is(err, os.ErrNotExist) {
+		if err := createCA(dir); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	return issue(dir, ServerFile, ServerKeyFile, hosts[0], hosts, x509.ExtKeyUsageServerAuth)
+}
+
+// IssueClientCert crée dans dir le certificat client name.pem (et sa clé
+// name-key.pem) signé par l'autorité. Son nom commun est name.
+func IssueClientCert(dir, name string) (certPath, keyPath string, err error) {
+	if name == "" {
+		return "", "", fmt.Errorf("missing client name")
+	}
+	certFile, keyFile := name+".pem", name+"-key.pem"
+	if err := issue(dir, certFile, keyFile, name, nil, x509.ExtKeyUsageClientAuth); err != nil {
+		return "", "", err
+	}
+	return filepath.Join(dir, certFile), filepath.Join(dir, keyFile), nil
+}
+
+// createCA génère la clé et le certificat auto-signé de l'autorité
+func createCA(dir string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	serial, err := serialNumber()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{organization}, CommonName: organization + " local CA"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return fmt.Errorf("failed to create CA certificate: %v", err)
+	}
+
+	return write(dir, CAFile, CAKeyFile, der, key)
+}
+
+// issue génère un certificat feuille signé par l'autorité de dir
+func issue(dir, certFile, keyFile, commonName string, hosts []string, usage x509.ExtKeyUsage) error {
+	caCert, caKey, err := loadCA(dir)
+	if err != nil {
+		return err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	serial, err := serialNumber()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{Organization: []string{organization}, CommonName: commonName},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(certValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return fmt.Errorf("failed to create certificate: %v", err)
+	}
+	return write(dir, certFile, keyFile, der, key)
+}
+
+// loadCA lit le certificat et la clé de l'autorité
+func loadCA(dir string) (*x509.Certificate, crypto.Signer, error) {
+	certPEM, err := os.ReadFile(filepath.Join(dir, CAFile))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read CA (run --init-ca first): %v", err)
+	}
+	keyPEM, err := os.ReadFile(filepath.Join(dir, CAKeyFile))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read CA key: %v", err)
+	}
+
+	certBlock, _ := pem.Decode(certPEM)
+	keyBlock, _ := pem.Decode(keyPEM)
+	if certBlock == nil || keyBlock == nil {
+		return nil, nil, fmt.Errorf("invalid CA files in %s", dir)
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CA certificate: %v", err)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CA key: %v", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid CA key type %T", key)
+	}
+	return cert, signer, nil
+}
+
+// write enregistre un certificat et sa clé au format PEM ; la clé n'est
+// lisible que par son propriétaire
+func write(dir, certFile, keyFile string, der []byte, key *ecdsa.PrivateKey) error {
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+
+	if err := os.WriteFile(filepath.Join(dir, keyFile), keyPEM, 0o600); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, certFile), certPEM, 0o644)
+}
+
+// serialNumber tire un numéro de série aléatoire de 128 bits
+func serialNumber() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
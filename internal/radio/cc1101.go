@@ -8,6 +8,8 @@ import (
 	"periph.io/x/conn/v3/physic"
 	"periph.io/x/conn/v3/spi"
 	"periph.io/x/conn/v3/spi/spireg"
+
+	"rtscommander/m/internal/remote"
 )
 
 // Configuration SPI
@@ -15,6 +17,15 @@ const (
 	spiSpeed = 1 * physic.MegaHertz
 )
 
+// Échantillonnage OOK : MDMCFG4/MDMCFG3 règlent le débit à ~12.5 kBaud,
+// soit 8 bits FIFO par demi-symbole Somfy de 640µs
+const (
+	BitPeriod = 80 * time.Microsecond
+	fifoSize  = 64
+	// Intervalle de remplissage du FIFO (64 octets durent ~41ms)
+	fifoPollInterval = 5 * time.Millisecond
+)
+
 // Registres CC1101
 const (
 	WriteBurst = 0x40
@@ -25,8 +36,14 @@ const (
 	SIDLE      = 0x36 // Idle
 	STX        = 0x35 // TX mode
 	SFTX       = 0x3B // Flush TX FIFO
+	PATABLE    = 0x3E // Table de puissance PA
+	TXFIFO     = 0x3F // FIFO TX
+	TXBYTES    = 0xFA // Octets dans le FIFO TX (registre d'état)
 )
 
+// Puissances PA pour l'OOK : index 0 = porteuse coupée, index 1 = ~+10 dBm
+var paTable = []byte{0x00, 0xC0}
+
 // Configuration du CC1101 pour Somfy RTS (433.42 MHz)
 var cc1101Config = map[byte]byte{
 	0x00: 0x0D, // IOCFG2
@@ -36,18 +53,18 @@ var cc1101Config = map[byte]byte{
 	0x04: 0xD3, // SYNC1
 	0x05: 0x91, // SYNC0
 	0x06: 0xFF, // PKTLEN
-	0x07: 0x04, // PKTCTRL1
-	0x08: 0x32, // PKTCTRL0 - Mode asynchrone
+	0x07: 0x00, // PKTCTRL1
+	0x08: 0x02, // PKTCTRL0 - FIFO, longueur infinie, sans CRC
 	0x09: 0x00, // ADDR
 	0x0A: 0x00, // CHANNR
 	0x0B: 0x06, // FSCTRL1
 	0x0C: 0x00, // FSCTRL0
 	0x0D: 0x10, // FREQ2 - 433.42 MHz
-	0x0E: 0xB0, // FREQ1
-	0x0F: 0x71, // FREQ0
-	0x10: 0xF8, // MDMCFG4 - Bande passante et débit
-	0x11: 0x93, // MDMCFG3
-	0x12: 0x03, // MDMCFG2 - Modulation ASK/OOK
+	0x0E: 0xAB, // FREQ1
+	0x0F: 0x85, // FREQ0
+	0x10: 0xF8, // MDMCFG4 - Bande passante et débit (DRATE_E = 8)
+	0x11: 0xF8, // MDMCFG3 - Débit 12.5 kBaud (DRATE_M = 248)
+	0x12: 0x30, // MDMCFG2 - Modulation ASK/OOK, sans préambule ni sync
 	0x13: 0x22, // MDMCFG1
 	0x14: 0xF8, // MDMCFG0
 	0x15: 0x15, // DEVIATN
@@ -57,7 +74,7 @@ var cc1101Config = map[byte]byte{
 	0x1C: 0x40, // AGCCTRL1
 	0x1D: 0x91, // AGCCTRL0
 	0x21: 0x56, // FREND1
-	0x22: 0x10, // FREND0
+	0x22: 0x11, // FREND0 - PA_POWER = 1 (OOK)
 	0x23: 0xE9, // FSCAL3
 	0x24: 0x2A, // FSCAL2
 	0x25: 0x00, // FSCAL1
@@ -67,6 +84,55 @@ var cc1101Config = map[byte]byte{
 	0x2E: 0x09, // TEST0
 }
 
+// CC1101 implémente Transmitter pour un module CC1101 branché en SPI
+type CC1101 struct {
+	conn spi.Conn
+}
+
+// NewCC1101 crée un émetteur à partir d'une connexion SPI initialisée
+func NewCC1101(conn spi.Conn) *CC1101 {
+	return &CC1101{conn: conn}
+}
+
+// Prepare met le CC1101 en mode idle et vide le FIFO TX
+func (c *CC1101) Prepare() error {
+	if err := WriteStrobe(c.conn, SIDLE); err != nil {
+		return fmt.Errorf("failed to set idle mode: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	if err := WriteStrobe(c.conn, SFTX); err != nil {
+		return fmt.Errorf("failed to flush TX FIFO: %v", err)
+	}
+	return nil
+}
+
+// Transmit échantillonne la forme d'onde au débit du CC1101 et la diffuse via le FIFO TX
+func (c *CC1101) Transmit(w remote.Waveform) error {
+	return TransmitStream(c.conn, Sample(w, BitPeriod))
+}
+
+// Sample convertit une forme d'onde en bits OOK (1 = porteuse) de durée period.
+// Les fronts sont arrondis sur la durée cumulée pour ne pas accumuler de dérive.
+func Sample(w remote.Waveform, period time.Duration) []byte {
+	total := int((w.Duration() + period/2) / period)
+	data := make([]byte, (total+7)/8)
+
+	var elapsed time.Duration
+	for _, p := range w {
+		start := int((elapsed + period/2) / period)
+		elapsed += p.Duration
+		end := int((elapsed + period/2) / period)
+		if !p.High {
+			continue
+		}
+		for bit := start; bit < end; bit++ {
+			data[bit/8] |= 0x80 >> uint(bit%8)
+		}
+	}
+	return data
+}
+
 // InitCC1101 initialise le module CC1101
 func InitCC1101() (spi.Conn, error) {
 	// Ouvrir la connexion SPI
@@ -93,6 +159,11 @@ func InitCC1101() (spi.Conn, error) {
 		}
 	}
 
+	// Table de puissance pour l'OOK
+	if err := writeBurst(conn, PATABLE, paTable); err != nil {
+		return nil, fmt.Errorf("failed to configure PATABLE: %v", err)
+	}
+
 	// Vérifier que le CC1101 est bien configuré
 	partnum, err := ReadRegister(conn, 0xF0) // PARTNUM
 	if err != nil {
@@ -150,7 +221,7 @@ func TestCC1101(conn spi.Conn) error {
 	freq1, _ := ReadRegister(conn, 0x0E)
 	freq0, _ := ReadRegister(conn, 0x0F)
 	fmt.Printf("  Fréquence (FREQ2/1/0): 0x%02X%02X%02X ", freq2, freq1, freq0)
-	if freq2 == 0x10 && freq1 == 0xB0 && freq0 == 0x71 {
+	if freq2 == 0x10 && freq1 == 0xAB && freq0 == 0x85 {
 		fmt.Println("✓ (433.42 MHz)")
 	} else {
 		fmt.Println("⚠")
@@ -159,7 +230,7 @@ func TestCC1101(conn spi.Conn) error {
 	// MDMCFG2 - Configuration modulation
 	mdmcfg2, _ := ReadRegister(conn, 0x12)
 	fmt.Printf("  Modulation (MDMCFG2): 0x%02X ", mdmcfg2)
-	if mdmcfg2 == 0x03 {
+	if mdmcfg2 == 0x30 {
 		fmt.Println("✓ (ASK/OOK)")
 	} else {
 		fmt.Println("⚠")
@@ -168,8 +239,8 @@ func TestCC1101(conn spi.Conn) error {
 	// PKTCTRL0 - Mode de paquet
 	pktctrl0, _ := ReadRegister(conn, 0x08)
 	fmt.Printf("  Mode paquet (PKTCTRL0): 0x%02X ", pktctrl0)
-	if pktctrl0 == 0x32 {
-		fmt.Println("✓ (Asynchrone)")
+	if pktctrl0 == 0x02 {
+		fmt.Println("✓ (FIFO, longueur infinie)")
 	} else {
 		fmt.Println("⚠")
 	}
@@ -211,25 +282,63 @@ func WriteStrobe(conn spi.Conn, strobe byte) error {
 	return conn.Tx(tx, rx)
 }
 
-// TransmitFrame transmet une trame via le CC1101
-func TransmitFrame(conn spi.Conn, frame []byte) error {
-	// Écrire la trame dans le FIFO TX (burst write)
-	tx := make([]byte, len(frame)+1)
-	tx[0] = 0x3F | WriteBurst // FIFO address
-	copy(tx[1:], frame)
+// writeBurst écrit plusieurs octets à partir d'une adresse (registres, PATABLE ou FIFO)
+func writeBurst(conn spi.Conn, addr byte, data []byte) error {
+	tx := make([]byte, len(data)+1)
+	tx[0] = addr | WriteBurst
+	copy(tx[1:], data)
 	rx := make([]byte, len(tx))
+	return conn.Tx(tx, rx)
+}
 
-	if err := conn.Tx(tx, rx); err != nil {
-		return err
+// TransmitStream émet un flux de bits OOK en remplissant le FIFO TX au fil de l'émission
+func TransmitStream(conn spi.Conn, data []byte) error {
+	// Pré-remplir le FIFO avant de lancer l'émission
+	n := min(len(data), fifoSize)
+	if err := writeBurst(conn, TXFIFO, data[:n]); err != nil {
+		return fmt.Errorf("failed to fill TX FIFO: %v", err)
 	}
+	data = data[n:]
 
-	// Lancer la transmission
 	if err := WriteStrobe(conn, STX); err != nil {
-		return err
+		return fmt.Errorf("failed to start TX: %v", err)
 	}
 
-	// Attendre la fin de la transmission
-	time.Sleep(100 * time.Millisecond)
+	// Compléter le FIFO au fur et à mesure qu'il se vide
+	for {
+		time.Sleep(fifoPollInterval)
 
-	return nil
+		status, err := ReadRegister(conn, TXBYTES)
+		if err != nil {
+			return fmt.Errorf("failed to read TXBYTES: %v", err)
+		}
+		pending := int(status & 0x7F)
+		underflow := status&0x80 != 0
+
+		if len(data) == 0 {
+			// Le flux se termine par un silence : le sous-débit final est attendu
+			if pending == 0 || underflow {
+				break
+			}
+			continue
+		}
+		if underflow {
+			return fmt.Errorf("TX FIFO underflow with %d bytes left", len(data))
+		}
+
+		n := min(len(data), fifoSize-pending)
+		if n == 0 {
+			continue
+		}
+		if err := writeBurst(conn, TXFIFO, data[:n]); err != nil {
+			return fmt.Errorf("failed to refill TX FIFO: %v", err)
+		}
+		data = data[n:]
+	}
+
+	// Revenir en idle et vider le FIFO (nécessaire après un sous-débit)
+	if err := WriteStrobe(conn, SIDLE); err != nil {
+		return err
+	}
+	return WriteStrobe(conn, SFTX)
 }
@@ -0,0 +1,122 @@
+package radio
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"rtscommander/m/internal/remote"
+)
+
+// Taille par défaut du tampon circulaire du simulateur
+const DefaultSimBufferSize = 256
+
+// SimRecord représente une trame capturée par le simulateur
+type SimRecord struct {
+	Time  time.Time `json:"time"`
+	Raw   []byte    `json:"raw,omitempty"`
+	Valid bool      `json:"valid"`
+	Error string    `json:"error,omitempty"`
+	remote.Frame
+}
+
+// Simulator implémente Transmitter sans matériel : les formes d'onde sont décodées,
+// journalisées et les trames conservées dans un tampon circulaire
+type Simulator struct {
+	mu      sync.Mutex
+	records []SimRecord
+	next    int
+	full    bool
+	out     io.Writer
+}
+
+// NewSimulator crée un émetteur simulé. Si out n'est pas nil, chaque trame y est
+// écrite en JSON (une ligne par trame).
+func NewSimulator(size int, out io.Writer) *Simulator {
+	if size <= 0 {
+		size = DefaultSimBufferSize
+	}
+	return &Simulator{
+		records: make([]SimRecord, size),
+		out:     out,
+	}
+}
+
+// Prepare ne fait rien pour le simulateur
+func (s *Simulator) Prepare() error {
+	return nil
+}
+
+// Transmit décode la forme d'onde et enregistre chaque trame qu'elle contient
+func (s *Simulator) Transmit(w remote.Waveform) error {
+	now := time.Now()
+	frames, err := remote.DecodeWaveform(w)
+
+	records := make([]SimRecord, 0, len(frames)+1)
+	for _, raw := range frames {
+		rec := SimRecord{Time: now, Raw: raw}
+		if frame, err := remote.DecodeFrame(raw); err != nil {
+			rec.Error = err.Error()
+		} else {
+			rec.Frame = *frame
+			rec.Valid = true
+		}
+		records = append(records, rec)
+	}
+	if err != nil {
+		records = append(records, SimRecord{Time: now, Error: err.Error()})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, rec := range records {
+		if err := s.record(rec); err != nil {
+			return err
+		}
+	}
+	log.Printf("[sim] Forme d'onde émise: %d impulsion(s), %v, %d trame(s)", len(w), w.Duration(), len(frames))
+	return nil
+}
+
+// record ajoute une trame au tampon circulaire et au journal
+func (s *Simulator) record(rec SimRecord) error {
+	s.records[s.next] = rec
+	s.next = (s.next + 1) % len(s.records)
+	if s.next == 0 {
+		s.full = true
+	}
+
+	if rec.Valid {
+		log.Printf("[sim] Trame: cmd=0x%X rolling=%d address=0x%06X", rec.Command, rec.RollingCode, rec.Address)
+	} else {
+		log.Printf("[sim] Trame invalide: %s", rec.Error)
+	}
+
+	if s.out != nil {
+		data, err := json.Marshal(rec)
+		if err != nil {
+			return fmt.Errorf("failed to marshal sim record: %v", err)
+		}
+		if _, err := s.out.Write(append(data, '\n')); err != nil {
+			return fmt.Errorf("failed to write sim record: %v", err)
+		}
+	}
+	return nil
+}
+
+// Records retourne les trames capturées, de la plus ancienne à la plus récente
+func (s *Simulator) Records() []SimRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.full {
+		return append([]SimRecord(nil), s.records[:s.next]...)
+	}
+	out := make([]SimRecord, 0, len(s.records))
+	out = append(out, s.records[s.next:]...)
+	return append(out, s.records[:s.next]...)
+}
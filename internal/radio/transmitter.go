@@ -0,0 +1,11 @@
+package radio
+
+import "rtscommander/m/internal/remote"
+
+// Transmitter représente un émetteur radio capable d'envoyer des trames RTS
+type Transmitter interface {
+	// Prepare place l'émetteur dans un état prêt à émettre
+	Prepare() error
+	// Transmit émet une forme d'onde OOK en respectant son chronogramme
+	Transmit(w remote.Waveform) error
+}
@@ -2,36 +2,126 @@ package remote
 
 import (
 	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
 )
 
+// Longueur d'une trame RTS en octets (56 bits)
+const FrameLen = 7
+
 // Commandes RTS Somfy
 const (
-	CmdMy   = 0x1 // Stop / My position
-	CmdUp   = 0x2 // Monter
-	CmdDown = 0x4 // Descendre
-	CmdProg = 0x8 // Programmation
+	CmdMy      = 0x1 // Stop / My position
+	CmdUp      = 0x2 // Monter
+	CmdMyUp    = 0x3 // My + Monter
+	CmdDown    = 0x4 // Descendre
+	CmdMyDown  = 0x5 // My + Descendre
+	CmdUpDown  = 0x6 // Monter + Descendre
+	CmdProg    = 0x8 // Programmation
+	CmdSunFlag = 0x9 // Capteur soleil + vent activé
+	CmdFlag    = 0xA // Capteur soleil désactivé (vent seul)
 )
 
+// Command décrit une commande RTS telle qu'exposée par la CLI et l'API :
+// un code et le nombre de répétitions émises (appui bref ou long)
+type Command struct {
+	Name    string
+	Aliases []string
+	Code    byte
+	Repeats int
+}
+
+// Commands liste les commandes disponibles. Les variantes "-long" simulent un
+// bouton maintenu en répétant la trame.
+var Commands = []Command{
+	{Name: "up", Aliases: []string{"monter"}, Code: CmdUp, Repeats: DefaultRepeats},
+	{Name: "down", Aliases: []string{"descendre"}, Code: CmdDown, Repeats: DefaultRepeats},
+	{Name: "my", Aliases: []string{"stop"}, Code: CmdMy, Repeats: DefaultRepeats},
+	{Name: "prog", Aliases: []string{"program"}, Code: CmdProg, Repeats: DefaultRepeats},
+	{Name: "my-up", Aliases: []string{"my+up"}, Code: CmdMyUp, Repeats: DefaultRepeats},
+	{Name: "my-down", Aliases: []string{"my+down"}, Code: CmdMyDown, Repeats: DefaultRepeats},
+	{Name: "up-down", Aliases: []string{"up+down"}, Code: CmdUpDown, Repeats: DefaultRepeats},
+	{Name: "sun-flag", Aliases: []string{"sun"}, Code: CmdSunFlag, Repeats: DefaultRepeats},
+	{Name: "flag", Code: CmdFlag, Repeats: DefaultRepeats},
+	// Appuis longs : désappairage, enregistrement de la position My, réglages
+	{Name: "prog-long", Code: CmdProg, Repeats: RepeatsFor(3 * time.Second)},
+	{Name: "my-long", Code: CmdMy, Repeats: RepeatsFor(5 * time.Second)},
+	{Name: "up-down-long", Code: CmdUpDown, Repeats: RepeatsFor(3 * time.Second)},
+}
+
+// ParseCommand retrouve une commande par son nom ou un alias (insensible à la casse)
+func ParseCommand(name string) (Command, bool) {
+	for _, cmd := range Commands {
+		if strings.EqualFold(cmd.Name, name) {
+			return cmd, true
+		}
+		for _, alias := range cmd.Aliases {
+			if strings.EqualFold(alias, name) {
+				return cmd, true
+			}
+		}
+	}
+	return Command{}, false
+}
+
+// CommandName retourne le nom de la commande correspondant à un code
+// (appui bref), ou le code en hexadécimal s'il est inconnu
+func CommandName(code byte) string {
+	for _, cmd := range Commands {
+		if cmd.Code == code {
+			return cmd.Name
+		}
+	}
+	return fmt.Sprintf("0x%X", code)
+}
+
+// CommandNames retourne les noms des commandes disponibles
+func CommandNames() []string {
+	names := make([]string, len(Commands))
+	for i, cmd := range Commands {
+		names[i] = cmd.Name
+	}
+	return names
+}
+
 // Control représente une télécommande virtuelle
 type Control struct {
 	Name          string `json:"name"`
 	Address       uint32 `json:"address"`        // Adresse de la télécommande (24 bits)
 	RollingCode   uint16 `json:"rolling_code"`   // Rolling code (16 bits)
 	EncryptionKey byte   `json:"encryption_key"` // Clé d'obfuscation
+
+	// Suivi de position (optionnel) : durées d'une course complète
+	TravelUpMs   int  `json:"travel_up_ms,omitempty"`   // Course complète en montée (ms)
+	TravelDownMs int  `json:"travel_down_ms,omitempty"` // Course complète en descente (ms)
+	MyPosition   *int `json:"my_position,omitempty"`    // Position favorite (%), si connue
+	Position     *int `json:"position,omitempty"`       // Position estimée (0 = fermé, 100 = ouvert)
+}
+
+// TracksPosition indique si les durées de course permettent d'estimer la position
+func (rc *Control) TracksPosition() bool {
+	return rc.TravelUpMs > 0 && rc.TravelDownMs > 0
+}
+
+// Frame représente le contenu d'une trame RTS décodée
+type Frame struct {
+	Key         byte   `json:"key"`
+	Command     byte   `json:"command"`
+	RollingCode uint16 `json:"rolling_code"`
+	Address     uint32 `json:"address"`
 }
 
 // BuildRTSFrame crée une trame RTS Somfy
 func (rc *Control) BuildRTSFrame(command byte) []byte {
 	// Trame Somfy RTS : 56 bits + sync
-	frame := make([]byte, 7)
+	frame := make([]byte, FrameLen)
 
 	// Octet 0 : clé (8 bits)
 	frame[0] = rc.EncryptionKey
 
-	// Octet 1 : commande (4 bits) + checksum (4 bits)
-	ctrl := command << 4
-	checksum := (ctrl ^ (ctrl >> 4) ^ byte(rc.RollingCode) ^ byte(rc.RollingCode>>8))
-	frame[1] = ctrl | (checksum & 0x0F)
+	// Octet 1 : commande (4 bits) + checksum (4 bits, calculé plus bas)
+	frame[1] = command << 4
 
 	// Octets 2-3 : rolling code (16 bits)
 	binary.BigEndian.PutUint16(frame[2:4], rc.RollingCode)
@@ -41,14 +131,52 @@ func (rc *Control) BuildRTSFrame(command byte) []byte {
 	frame[5] = byte(rc.Address >> 8)
 	frame[6] = byte(rc.Address)
 
+	// Checksum : XOR de tous les quartets de la trame
+	frame[1] |= checksum(frame)
+
 	// Obfuscation de la trame
-	for i := 1; i < 7; i++ {
+	for i := 1; i < FrameLen; i++ {
 		frame[i] ^= frame[i-1]
 	}
 
 	return frame
 }
 
+// DecodeFrame désobfusque une trame RTS, vérifie son checksum et en extrait les champs
+func DecodeFrame(data []byte) (*Frame, error) {
+	if len(data) != FrameLen {
+		return nil, fmt.Errorf("invalid frame length: %d (expected %d)", len(data), FrameLen)
+	}
+
+	// Désobfuscation (inverse du XOR en cascade)
+	frame := make([]byte, FrameLen)
+	frame[0] = data[0]
+	for i := 1; i < FrameLen; i++ {
+		frame[i] = data[i] ^ data[i-1]
+	}
+
+	// Un checksum valide annule le XOR de tous les quartets
+	if sum := checksum(frame); sum != 0 {
+		return nil, fmt.Errorf("invalid checksum (residue 0x%X)", sum)
+	}
+
+	return &Frame{
+		Key:         frame[0],
+		Command:     frame[1] >> 4,
+		RollingCode: binary.BigEndian.Uint16(frame[2:4]),
+		Address:     uint32(frame[4])<<16 | uint32(frame[5])<<8 | uint32(frame[6]),
+	}, nil
+}
+
+// checksum calcule le XOR des quartets d'une trame non obfusquée
+func checksum(frame []byte) byte {
+	var sum byte
+	for _, b := range frame {
+		sum ^= b ^ (b >> 4)
+	}
+	return sum & 0x0F
+}
+
 // ManchesterEncode encode une trame en Manchester
 func ManchesterEncode(data []byte) []byte {
 	encoded := make([]byte, 0, len(data)*2)
@@ -63,3 +191,25 @@ func ManchesterEncode(data []byte) []byte {
 	}
 	return encoded
 }
+
+// ManchesterDecode décode une trame produite par ManchesterEncode
+func ManchesterDecode(encoded []byte) ([]byte, error) {
+	if len(encoded)%8 != 0 {
+		return nil, fmt.Errorf("invalid encoded length: %d (expected a multiple of 8)", len(encoded))
+	}
+
+	data := make([]byte, len(encoded)/8)
+	for i, symbol := range encoded {
+		var bit byte
+		switch symbol {
+		case 0xAA: // 10 -> 1
+			bit = 1
+		case 0x55: // 01 -> 0
+			bit = 0
+		default:
+			return nil, fmt.Errorf("invalid Manchester symbol 0x%02X at bit %d", symbol, i)
+		}
+		data[i/8] |= bit << uint(7-i%8)
+	}
+	return data, nil
+}
@@ -0,0 +1,200 @@
+package remote
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Valeurs limites d'adresse et de rolling code
+var (
+	testAddresses    = []uint32{0, 0x000001, 0x123456, 0xFFFFFF}
+	testRollingCodes = []uint16{0, 1, 0x7FFF, 0x8000, 0xFFFE, 0xFFFF}
+)
+
+// commandCodes retourne les codes distincts des commandes disponibles
+func commandCodes() []byte {
+	seen := make(map[byte]bool)
+	var codes []byte
+	for _, cmd := range Commands {
+		if !seen[cmd.Code] {
+			seen[cmd.Code] = true
+			codes = append(codes, cmd.Code)
+		}
+	}
+	return codes
+}
+
+// deobfuscate inverse le XOR en cascade de BuildRTSFrame
+func deobfuscate(data []byte) []byte {
+	frame := make([]byte, len(data))
+	frame[0] = data[0]
+	for i := 1; i < len(data); i++ {
+		frame[i] = data[i] ^ data[i-1]
+	}
+	return frame
+}
+
+// obfuscate applique le XOR en cascade à une trame en clair
+func obfuscate(frame []byte) []byte {
+	data := append([]byte(nil), frame...)
+	for i := 1; i < len(data); i++ {
+		data[i] ^= data[i-1]
+	}
+	return data
+}
+
+func TestFrameRoundTrip(t *testing.T) {
+	for _, code := range commandCodes() {
+		for _, address := range testAddresses {
+			for _, rolling := range testRollingCodes {
+				rc := &Control{Address: address, RollingCode: rolling, EncryptionKey: 0xA7}
+				data := rc.BuildRTSFrame(code)
+				if len(data) != FrameLen {
+					t.Fatalf("frame length = %d, want %d", len(data), FrameLen)
+				}
+
+				frame, err := DecodeFrame(data)
+				if err != nil {
+					t.Fatalf("cmd 0x%X address 0x%06X rolling %d: %v", code, address, rolling, err)
+				}
+				want := Frame{Key: 0xA7, Command: code, RollingCode: rolling, Address: address}
+				if *frame != want {
+					t.Errorf("decoded %+v, want %+v", *frame, want)
+				}
+			}
+		}
+	}
+}
+
+func TestFrameLayout(t *testing.T) {
+	rc := &Control{Address: 0x123456, RollingCode: 0xABCD, EncryptionKey: 0xA7}
+	plain := deobfuscate(rc.BuildRTSFrame(CmdUp))
+
+	want := []byte{0xA7, CmdUp << 4, 0xAB, 0xCD, 0x12, 0x34, 0x56}
+	if got := plain[1] & 0xF0; got != want[1] {
+		t.Errorf("command nibble = 0x%02X, want 0x%02X", got, want[1])
+	}
+	plain[1] &= 0xF0
+	if !bytes.Equal(plain, want) {
+		t.Errorf("plain frame = % X, want % X", plain, want)
+	}
+}
+
+func TestChecksumCancelsNibbles(t *testing.T) {
+	for _, code := range commandCodes() {
+		rc := &Control{Address: 0xFFFFFF, RollingCode: 0xFFFF, EncryptionKey: 0xFF}
+		if sum := checksum(deobfuscate(rc.BuildRTSFrame(code))); sum != 0 {
+			t.Errorf("cmd 0x%X: checksum residue 0x%X, want 0", code, sum)
+		}
+	}
+}
+
+func TestRollingCodeWrapAround(t *testing.T) {
+	rc := &Control{Address: 0x123456, RollingCode: 0xFFFF}
+
+	for _, want := range []uint16{0xFFFF, 0, 1} {
+		frame, err := DecodeFrame(rc.BuildRTSFrame(CmdMy))
+		if err != nil {
+			t.Fatalf("rolling %d: %v", want, err)
+		}
+		if frame.RollingCode != want {
+			t.Errorf("rolling code = %d, want %d", frame.RollingCode, want)
+		}
+		rc.RollingCode++
+	}
+}
+
+func TestDecodeFrameRejectsCorruption(t *testing.T) {
+	rc := &Control{Address: 0x123456, RollingCode: 42, EncryptionKey: 0xA7}
+	plain := deobfuscate(rc.BuildRTSFrame(CmdDown))
+
+	tests := []struct {
+		name  string
+		index int
+		mask  byte
+	}{
+		{"checksum", 1, 0x01},
+		{"command", 1, 0x10},
+		{"key", 0, 0x08},
+		{"rolling code high nibble", 2, 0x80},
+		{"rolling code low nibble", 3, 0x04},
+		{"address", 5, 0x20},
+		{"last nibble", 6, 0x01},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			corrupted := append([]byte(nil), plain...)
+			corrupted[tt.index] ^= tt.mask
+			if _, err := DecodeFrame(obfuscate(corrupted)); err == nil {
+				t.Errorf("corrupted frame accepted")
+			}
+		})
+	}
+}
+
+func TestDecodeFrameRejectsLength(t *testing.T) {
+	for _, n := range []int{0, FrameLen - 1, FrameLen + 1} {
+		if _, err := DecodeFrame(make([]byte, n)); err == nil {
+			t.Errorf("frame of %d bytes accepted", n)
+		}
+	}
+}
+
+func TestManchesterRoundTrip(t *testing.T) {
+	tests := [][]byte{
+		{},
+		{0x00},
+		{0xFF},
+		{0xA5, 0x5A},
+		(&Control{Address: 0xFFFFFF, RollingCode: 0xFFFF}).BuildRTSFrame(CmdProg),
+	}
+	for _, data := range tests {
+		encoded := ManchesterEncode(data)
+		if len(encoded) != len(data)*8 {
+			t.Fatalf("encoded length = %d, want %d", len(encoded), len(data)*8)
+		}
+		decoded, err := ManchesterDecode(encoded)
+		if err != nil {
+			t.Fatalf("% X: %v", data, err)
+		}
+		if !bytes.Equal(decoded, data) {
+			t.Errorf("decoded % X, want % X", decoded, data)
+		}
+	}
+}
+
+func TestManchesterDecodeRejectsCorruption(t *testing.T) {
+	encoded := ManchesterEncode([]byte{0x12, 0x34})
+
+	for _, symbol := range []byte{0x00, 0xFF, 0x5A, 0xA5} {
+		corrupted := append([]byte(nil), encoded...)
+		corrupted[3] = symbol
+		if _, err := ManchesterDecode(corrupted); err == nil {
+			t.Errorf("symbol 0x%02X accepted", symbol)
+		}
+	}
+	if _, err := ManchesterDecode(encoded[:7]); err == nil {
+		t.Errorf("truncated encoding accepted")
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		code byte
+		ok   bool
+	}{
+		{"up", CmdUp, true},
+		{"MONTER", CmdUp, true},
+		{"stop", CmdMy, true},
+		{"my+down", CmdMyDown, true},
+		{"prog-long", CmdProg, true},
+		{"sideways", 0, false},
+	}
+	for _, tt := range tests {
+		cmd, ok := ParseCommand(tt.name)
+		if ok != tt.ok || cmd.Code != tt.code {
+			t.Errorf("ParseCommand(%q) = 0x%X, %v; want 0x%X, %v", tt.name, cmd.Code, ok, tt.code, tt.ok)
+		}
+	}
+}
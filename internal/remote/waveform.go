@@ -0,0 +1,192 @@
+package remote
+
+import (
+	"fmt"
+	"time"
+)
+
+// Durées du signal Somfy RTS
+const (
+	WakeupHigh       = 9415 * time.Microsecond  // Impulsion de réveil (première trame)
+	WakeupLow        = 89565 * time.Microsecond // Silence après le réveil
+	HardwareSyncHalf = 2416 * time.Microsecond  // Demi-période d'une impulsion de sync matérielle
+	SoftwareSyncHigh = 4550 * time.Microsecond  // Sync logicielle
+	HalfSymbol       = 640 * time.Microsecond   // Demi-symbole Manchester (sync logicielle incluse)
+	InterFrameGap    = 30415 * time.Microsecond // Silence entre deux trames
+)
+
+// Nombre d'impulsions de sync matérielle
+const (
+	FirstFrameSyncs  = 2 // Première trame
+	RepeatFrameSyncs = 7 // Répétitions
+)
+
+// Nombre de répétitions émises après la première trame lors d'un appui bref,
+// comme une télécommande Somfy
+const DefaultRepeats = 2
+
+// Pulse représente un niveau radio (porteuse ou silence) maintenu pendant une durée
+type Pulse struct {
+	High     bool
+	Duration time.Duration
+}
+
+// Waveform représente une séquence d'impulsions à émettre en OOK
+type Waveform []Pulse
+
+// Duration retourne la durée totale de la forme d'onde
+func (w Waveform) Duration() time.Duration {
+	var d time.Duration
+	for _, p := range w {
+		d += p.Duration
+	}
+	return d
+}
+
+// add ajoute une impulsion en fusionnant avec la précédente si le niveau est identique
+func (w Waveform) add(high bool, d time.Duration) Waveform {
+	if n := len(w); n > 0 && w[n-1].High == high {
+		w[n-1].Duration += d
+		return w
+	}
+	return append(w, Pulse{High: high, Duration: d})
+}
+
+// BuildWaveform construit la forme d'onde d'une trame (obfusquée) suivie de
+// repeats répétitions, selon le chronogramme Somfy RTS
+func BuildWaveform(frame []byte, repeats int) Waveform {
+	var w Waveform
+
+	// Réveil du récepteur
+	w = w.add(true, WakeupHigh)
+	w = w.add(false, WakeupLow)
+
+	for i := 0; i <= repeats; i++ {
+		syncs := RepeatFrameSyncs
+		if i == 0 {
+			syncs = FirstFrameSyncs
+		}
+		w = w.appendFrame(frame, syncs)
+	}
+	return w
+}
+
+// RepeatsFor retourne le nombre de répétitions nécessaires pour simuler un
+// bouton maintenu pendant hold
+func RepeatsFor(hold time.Duration) int {
+	first := WakeupHigh + WakeupLow + frameDuration(FirstFrameSyncs)
+	if hold <= first {
+		return 0
+	}
+	repeat := frameDuration(RepeatFrameSyncs)
+	return int((hold - first + repeat - 1) / repeat)
+}
+
+// frameDuration retourne la durée d'une trame (syncs, données et silence final)
+func frameDuration(syncs int) time.Duration {
+	return time.Duration(syncs)*2*HardwareSyncHalf + SoftwareSyncHigh + HalfSymbol +
+		FrameLen*8*2*HalfSymbol + InterFrameGap
+}
+
+// appendFrame ajoute les syncs, la trame encodée en Manchester et le silence final
+func (w Waveform) appendFrame(frame []byte, syncs int) Waveform {
+	// Sync matérielle
+	for i := 0; i < syncs; i++ {
+		w = w.add(true, HardwareSyncHalf)
+		w = w.add(false, HardwareSyncHalf)
+	}
+
+	// Sync logicielle
+	w = w.add(true, SoftwareSyncHigh)
+	w = w.add(false, HalfSymbol)
+
+	// Données : 1 = front montant, 0 = front descendant
+	for _, b := range frame {
+		for i := 7; i >= 0; i-- {
+			bit := (b>>uint(i))&1 == 1
+			w = w.add(!bit, HalfSymbol)
+			w = w.add(bit, HalfSymbol)
+		}
+	}
+
+	return w.add(false, InterFrameGap)
+}
+
+// DecodeWaveform retrouve les trames (obfusquées) contenues dans une forme d'onde
+func DecodeWaveform(w Waveform) ([][]byte, error) {
+	var frames [][]byte
+
+	for i := 0; i < len(w); i++ {
+		// Une sync logicielle marque le début des données
+		if !w[i].High || !near(w[i].Duration, SoftwareSyncHigh) {
+			continue
+		}
+
+		frame, next, err := decodeManchester(w, i+1)
+		if err != nil {
+			return frames, fmt.Errorf("frame %d: %v", len(frames)+1, err)
+		}
+		frames = append(frames, frame)
+		i = next - 1
+	}
+
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("no software sync found")
+	}
+	return frames, nil
+}
+
+// decodeManchester lit FrameLen octets à partir de l'impulsion suivant la sync logicielle
+// et retourne l'index de la première impulsion non consommée
+func decodeManchester(w Waveform, start int) ([]byte, int, error) {
+	const halves = FrameLen * 8 * 2
+
+	// Découper les impulsions en demi-symboles
+	levels := make([]bool, 0, halves+1)
+	i := start
+	for ; i < len(w) && len(levels) < halves+1; i++ {
+		n := int((w[i].Duration + HalfSymbol/2) / HalfSymbol)
+		if n < 1 {
+			return nil, i, fmt.Errorf("pulse too short: %v", w[i].Duration)
+		}
+		// La dernière impulsion peut se prolonger dans le silence inter-trame
+		if missing := halves + 1 - len(levels); n > missing {
+			n = missing
+		} else if n > 2 && i != start {
+			return nil, i, fmt.Errorf("pulse too long: %v", w[i].Duration)
+		}
+		for ; n > 0; n-- {
+			levels = append(levels, w[i].High)
+		}
+	}
+	if len(levels) < halves+1 {
+		return nil, i, fmt.Errorf("truncated frame: %d half-symbols", len(levels)-1)
+	}
+
+	// Le premier demi-symbole bas appartient à la sync logicielle
+	if levels[0] {
+		return nil, i, fmt.Errorf("missing low half-symbol after software sync")
+	}
+	levels = levels[1:]
+
+	frame := make([]byte, FrameLen)
+	for bit := 0; bit < FrameLen*8; bit++ {
+		first, second := levels[2*bit], levels[2*bit+1]
+		if first == second {
+			return nil, i, fmt.Errorf("invalid Manchester symbol at bit %d", bit)
+		}
+		if second {
+			frame[bit/8] |= 1 << uint(7-bit%8)
+		}
+	}
+	return frame, i, nil
+}
+
+// near indique si une durée correspond à la valeur attendue (±20%)
+func near(d, expected time.Duration) bool {
+	delta := d - expected
+	if delta < 0 {
+		delta = -delta
+	}
+	return delta <= expected/5
+}
@@ -0,0 +1,139 @@
+package remote
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestWaveformRoundTrip(t *testing.T) {
+	for _, code := range commandCodes() {
+		for _, address := range testAddresses {
+			for _, rolling := range testRollingCodes {
+				rc := &Control{Address: address, RollingCode: rolling, EncryptionKey: 0xA0}
+				data := rc.BuildRTSFrame(code)
+
+				frames, err := DecodeWaveform(BuildWaveform(data, DefaultRepeats))
+				if err != nil {
+					t.Fatalf("cmd 0x%X address 0x%06X rolling %d: %v", code, address, rolling, err)
+				}
+				if len(frames) != DefaultRepeats+1 {
+					t.Fatalf("decoded %d frames, want %d", len(frames), DefaultRepeats+1)
+				}
+				for _, raw := range frames {
+					if !bytes.Equal(raw, data) {
+						t.Fatalf("decoded % X, want % X", raw, data)
+					}
+					frame, err := DecodeFrame(raw)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if frame.Command != code || frame.Address != address || frame.RollingCode != rolling {
+						t.Errorf("decoded %+v", *frame)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestWaveformRepeats(t *testing.T) {
+	data := (&Control{Address: 0x123456, RollingCode: 7}).BuildRTSFrame(CmdUp)
+
+	for _, repeats := range []int{0, 1, 7, RepeatsFor(3 * time.Second)} {
+		w := BuildWaveform(data, repeats)
+		frames, err := DecodeWaveform(w)
+		if err != nil {
+			t.Fatalf("%d repeats: %v", repeats, err)
+		}
+		if len(frames) != repeats+1 {
+			t.Errorf("%d repeats: decoded %d frames", repeats, len(frames))
+		}
+
+		want := WakeupHigh + WakeupLow + frameDuration(FirstFrameSyncs) + time.Duration(repeats)*frameDuration(RepeatFrameSyncs)
+		if got := w.Duration(); got != want {
+			t.Errorf("%d repeats: duration %v, want %v", repeats, got, want)
+		}
+	}
+}
+
+func TestRepeatsFor(t *testing.T) {
+	first := WakeupHigh + WakeupLow + frameDuration(FirstFrameSyncs)
+	repeat := frameDuration(RepeatFrameSyncs)
+
+	tests := []struct {
+		hold time.Duration
+		want int
+	}{
+		{0, 0},
+		{first, 0},
+		{first + 1, 1},
+		{first + repeat, 1},
+		{first + repeat + 1, 2},
+	}
+	for _, tt := range tests {
+		if got := RepeatsFor(tt.hold); got != tt.want {
+			t.Errorf("RepeatsFor(%v) = %d, want %d", tt.hold, got, tt.want)
+		}
+	}
+}
+
+func TestWaveformWithCorruptedNibble(t *testing.T) {
+	plain := deobfuscate((&Control{Address: 0xFFFFFF, RollingCode: 0}).BuildRTSFrame(CmdMy))
+	plain[6] ^= 0x08
+
+	// La forme d'onde reste lisible, la trame est refusée au checksum
+	frames, err := DecodeWaveform(BuildWaveform(obfuscate(plain), 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodeFrame(frames[0]); err == nil {
+		t.Errorf("corrupted frame accepted")
+	}
+}
+
+func TestDecodeWaveformRejectsCorruption(t *testing.T) {
+	data := (&Control{Address: 0x123456, RollingCode: 42}).BuildRTSFrame(CmdDown)
+	w := BuildWaveform(data, 0)
+
+	// Première impulsion de données après la sync logicielle
+	sync := -1
+	for i, p := range w {
+		if p.High && p.Duration == SoftwareSyncHigh {
+			sync = i
+			break
+		}
+	}
+	if sync < 0 {
+		t.Fatal("software sync not found")
+	}
+
+	tests := []struct {
+		name    string
+		corrupt func(w Waveform) Waveform
+	}{
+		{"no sync", func(w Waveform) Waveform {
+			w[sync].Duration = HardwareSyncHalf
+			return w
+		}},
+		{"pulse too long", func(w Waveform) Waveform {
+			w[sync+3].Duration = 3 * HalfSymbol
+			return w
+		}},
+		{"pulse too short", func(w Waveform) Waveform {
+			w[sync+3].Duration = HalfSymbol / 4
+			return w
+		}},
+		{"truncated", func(w Waveform) Waveform {
+			return w[:sync+20]
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			corrupted := tt.corrupt(append(Waveform(nil), w...))
+			if _, err := DecodeWaveform(corrupted); err == nil {
+				t.Errorf("corrupted waveform accepted")
+			}
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"rtscommander/m/internal/config"
+	"rtscommander/m/internal/controller"
+	"rtscommander/m/internal/remote"
+	"rtscommander/m/internal/solar"
+)
+
+// Noms des jours acceptés dans une programmation
+var dayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday, "dim": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday, "lun": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday, "mar": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday, "mer": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday, "jeu": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday, "ven": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday, "sam": time.Saturday,
+}
+
+// Validate vérifie le format d'une programmation (jours, heure, commande)
+func Validate(sc config.Schedule) error {
+	if _, err := weekdays(sc.Days); err != nil {
+		return err
+	}
+	if _, err := parseTime(sc.Time); err != nil {
+		return err
+	}
+	if strings.EqualFold(sc.Command, controller.PositionCommand) {
+		if sc.Value == nil {
+			return fmt.Errorf("missing value for position command")
+		}
+		return nil
+	}
+	if _, ok := remote.ParseCommand(sc.Command); !ok {
+		return fmt.Errorf("unknown command: %s", sc.Command)
+	}
+	return nil
+}
+
+// Parse lit une programmation textuelle :
+//
+//	<jours> <heure> <commande> [valeur] <télécommande | group <groupe>>
+//
+// où l'heure est "HH:MM" ou relative au soleil ("sunrise", "sunset+15min",
+// "sunrise - 10min"), par exemple "weekdays 07:30 up salon",
+// "every day sunset+15min down group rdc" ou "mon,wed,fri 08:00 position 40 bureau".
+func Parse(spec string) (config.Schedule, error) {
+	var sc config.Schedule
+	tokens := strings.Fields(strings.ReplaceAll(spec, "−", "-"))
+
+	// Mots-clés, jours, heure et commande sont insensibles à la casse ; le nom
+	// de la cible, en dernier, est gardé tel quel
+	for i := 0; i < len(tokens)-1; i++ {
+		tokens[i] = strings.ToLower(tokens[i])
+	}
+
+	// Jours
+	switch {
+	case len(tokens) >= 2 && tokens[0] == "every" && tokens[1] == "day":
+		tokens = tokens[2:]
+	case len(tokens) >= 1 && (tokens[0] == "daily" || tokens[0] == "everyday"):
+		tokens = tokens[1:]
+	case len(tokens) >= 1:
+		sc.Days = strings.Split(tokens[0], ",")
+		tokens = tokens[1:]
+	}
+
+	// Décalage solaire écrit avec des espaces : "sunset + 15min"
+	if len(tokens) >= 2 && strings.HasPrefix(tokens[0], "sun") {
+		if tokens[1] == "+" || tokens[1] == "-" {
+			if len(tokens) >= 3 {
+				tokens = append([]string{tokens[0] + tokens[1] + tokens[2]}, tokens[3:]...)
+			}
+		} else if strings.HasPrefix(tokens[1], "+") || strings.HasPrefix(tokens[1], "-") {
+			tokens = append([]string{tokens[0] + tokens[1]}, tokens[2:]...)
+		}
+	}
+
+	if len(tokens) < 3 {
+		return sc, fmt.Errorf("invalid schedule '%s' (expected: <days> <HH:MM|sunrise|sunset[+-offset]> <command> [value] <remote|group name>)", spec)
+	}
+	sc.Time, sc.Command = tokens[0], tokens[1]
+	tokens = tokens[2:]
+
+	if sc.Command == controller.PositionCommand {
+		value, err := strconv.Atoi(tokens[0])
+		if err != nil {
+			return sc, fmt.Errorf("invalid position value '%s'", tokens[0])
+		}
+		sc.Value = &value
+		tokens = tokens[1:]
+	}
+
+	switch {
+	case len(tokens) == 2 && tokens[0] == "group":
+		sc.Group = tokens[1]
+	case len(tokens) == 1:
+		sc.Remote = tokens[0]
+	default:
+		return sc, fmt.Errorf("invalid target in schedule '%s'", spec)
+	}
+
+	target := sc.Remote
+	if sc.Group != "" {
+		target = sc.Group
+	}
+	days := "daily"
+	if len(sc.Days) > 0 {
+		days = strings.Join(sc.Days, "_")
+	}
+	sc.Name = fmt.Sprintf("%s-%s-%s-%s", target, sc.Command, days, strings.NewReplacer(":", "", "+", "p", "-", "m").Replace(sc.Time))
+
+	return sc, Validate(sc)
+}
+
+// Site représente le fuseau et les coordonnées utilisés pour calculer les occurrences
+type Site struct {
+	Location  *time.Location
+	Latitude  float64
+	Longitude float64
+	HasCoords bool // Coordonnées connues (nécessaires pour sunrise/sunset)
+}
+
+// SiteFor retourne le site d'une programmation : son fuseau ou à défaut celui
+// de la configuration, et les coordonnées de l'installation
+func SiteFor(cfg *config.Config, sc config.Schedule) (Site, error) {
+	tz := sc.Timezone
+	if tz == "" {
+		tz = cfg.Timezone()
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return Site{}, err
+	}
+	site := Site{Location: loc}
+	site.Latitude, site.Longitude, site.HasCoords = cfg.Coordinates()
+	return site, nil
+}
+
+// NextRun retourne la prochaine exécution d'une programmation
+func NextRun(cfg *config.Config, sc config.Schedule, after time.Time) (time.Time, bool) {
+	if sc.Disabled {
+		return time.Time{}, false
+	}
+	site, err := SiteFor(cfg, sc)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return Next(sc, site, after)
+}
+
+// IsSunRelative indique si une programmation dépend du lever ou du coucher du soleil
+func IsSunRelative(sc config.Schedule) bool {
+	spec, err := parseTime(sc.Time)
+	return err == nil && spec.event != ""
+}
+
+// CheckSite vérifie que la configuration permet de calculer les occurrences
+// d'une programmation (coordonnées requises pour sunrise/sunset)
+func CheckSite(cfg *config.Config, sc config.Schedule) error {
+	if !IsSunRelative(sc) {
+		return nil
+	}
+	if _, _, ok := cfg.Coordinates(); !ok {
+		return fmt.Errorf("schedule '%s' is relative to the sun: set the location coordinates first (--latitude/--longitude)", sc.Name)
+	}
+	return nil
+}
+
+// Next retourne la prochaine occurrence strictement postérieure à after
+func Next(sc config.Schedule, site Site, after time.Time) (time.Time, bool) {
+	day := after.In(site.Location)
+	for offset := -1; offset <= 8; offset++ {
+		if t, ok := occurrenceOn(sc, site, day.AddDate(0, 0, offset)); ok && t.After(after) {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Previous retourne la dernière occurrence antérieure ou égale à now
+func Previous(sc config.Schedule, site Site, now time.Time) (time.Time, bool) {
+	day := now.In(site.Location)
+	for offset := -1; offset <= 8; offset++ {
+		if t, ok := occurrenceOn(sc, site, day.AddDate(0, 0, -offset)); ok && !t.After(now) {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// occurrenceOn retourne l'occurrence d'une programmation le jour de day
+func occurrenceOn(sc config.Schedule, site Site, day time.Time) (time.Time, bool) {
+	days, err := weekdays(sc.Days)
+	if err != nil || !days[day.Weekday()] {
+		return time.Time{}, false
+	}
+	spec, err := parseTime(sc.Time)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	date := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, site.Location)
+	switch spec.event {
+	case "":
+		return time.Date(day.Year(), day.Month(), day.Day(), spec.hour, spec.minute, 0, 0, site.Location), true
+	case eventSunrise, eventSunset:
+		if !site.HasCoords {
+			return time.Time{}, false
+		}
+		rise, set, ok := solar.Times(date, site.Latitude, site.Longitude)
+		if !ok {
+			// Nuit ou jour polaire : pas d'occurrence ce jour-là
+			return time.Time{}, false
+		}
+		if spec.event == eventSunrise {
+			return rise.Add(spec.offset), true
+		}
+		return set.Add(spec.offset), true
+	}
+	return time.Time{}, false
+}
+
+// weekdays convertit la liste des jours d'une programmation (vide = tous les jours)
+func weekdays(days []string) (map[time.Weekday]bool, error) {
+	set := make(map[time.Weekday]bool, 7)
+	if len(days) == 0 {
+		days = []string{"weekdays", "weekend"}
+	}
+
+	for _, day := range days {
+		switch day = strings.ToLower(strings.TrimSpace(day)); day {
+		case "weekdays":
+			for wd := time.Monday; wd <= time.Friday; wd++ {
+				set[wd] = true
+			}
+		case "weekend":
+			set[time.Saturday] = true
+			set[time.Sunday] = true
+		default:
+			wd, ok := dayNames[day]
+			if !ok {
+				return nil, fmt.Errorf("unknown day '%s'", day)
+			}
+			set[wd] = true
+		}
+	}
+	return set, nil
+}
+
+// Événements solaires utilisables comme heure d'une programmation
+const (
+	eventSunrise = "sunrise"
+	eventSunset  = "sunset"
+)
+
+// timeSpec représente l'heure d'une programmation : fixe ou relative au soleil
+type timeSpec struct {
+	event        string        // "", eventSunrise ou eventSunset
+	offset       time.Duration // Décalage par rapport à l'événement
+	hour, minute int           // Heure fixe
+}
+
+// parseTime lit "HH:MM", "sunrise", "sunset" ou "sunset+15min", "sunrise-1h"
+// (un décalage sans unité est exprimé en minutes)
+func parseTime(value string) (timeSpec, error) {
+	value = strings.ToLower(strings.ReplaceAll(value, " ", ""))
+
+	for _, event := range []string{eventSunrise, eventSunset} {
+		if !strings.HasPrefix(value, event) {
+			continue
+		}
+		spec := timeSpec{event: event}
+		offset := strings.TrimPrefix(value, event)
+		if offset == "" {
+			return spec, nil
+		}
+		if offset[0] != '+' && offset[0] != '-' {
+			break
+		}
+		offset = strings.ReplaceAll(offset, "min", "m")
+		if _, err := strconv.Atoi(offset); err == nil {
+			offset += "m"
+		}
+		d, err := time.ParseDuration(offset)
+		if err != nil {
+			return spec, fmt.Errorf("invalid offset in '%s'", value)
+		}
+		spec.offset = d
+		return spec, nil
+	}
+
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return timeSpec{}, fmt.Errorf("invalid time '%s' (expected HH:MM, sunrise or sunset[+-offset])", value)
+	}
+	return timeSpec{hour: t.Hour(), minute: t.Minute()}, nil
+}
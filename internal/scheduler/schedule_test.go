@@ -0,0 +1,100 @@
+package scheduler
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"rtscommander/m/internal/config"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec   string
+		name   string
+		remote string
+		group  string
+		days   []string
+		time   string
+	}{
+		{"weekdays 07:30 up Salon", "Salon-up-weekdays-0730", "Salon", "", []string{"weekdays"}, "07:30"},
+		{"Every Day sunset + 15min DOWN group Etage", "Etage-down-daily-sunsetp15min", "", "Etage", nil, "sunset+15min"},
+		// Mêmes heure et cible, jours différents : noms distincts
+		{"weekend 08:00 up salon", "salon-up-weekend-0800", "salon", "", []string{"weekend"}, "08:00"},
+		{"mon,wed 08:00 up salon", "salon-up-mon_wed-0800", "salon", "", []string{"mon", "wed"}, "08:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			sc, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sc.Name != tt.name || sc.Remote != tt.remote || sc.Group != tt.group || sc.Time != tt.time {
+				t.Errorf("schedule = %+v", sc)
+			}
+			if !slices.Equal(sc.Days, tt.days) {
+				t.Errorf("days = %v, want %v", sc.Days, tt.days)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"", "daily 07:30 up", "daily 25:00 up salon", "daily 07:30 fly salon", "daily 07:30 position salon", "mon-fri 08:00 up salon"} {
+		if _, err := Parse(invalid); err == nil {
+			t.Errorf("%q accepted", invalid)
+		}
+	}
+}
+
+func TestNextPrevious(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip(err)
+	}
+	site := Site{Location: paris}
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, paris)
+	}
+
+	tests := []struct {
+		name     string
+		days     []string
+		time     string
+		from     time.Time
+		next     time.Time
+		previous time.Time
+	}{
+		// Vendredi 16 octobre 2026
+		{"weekdays before time", []string{"weekdays"}, "07:30", at(10, 16, 7, 0), at(10, 16, 7, 30), at(10, 15, 7, 30)},
+		{"weekdays at time", []string{"weekdays"}, "07:30", at(10, 16, 7, 30), at(10, 19, 7, 30), at(10, 16, 7, 30)},
+		{"weekdays over weekend", []string{"weekdays"}, "07:30", at(10, 18, 12, 0), at(10, 19, 7, 30), at(10, 16, 7, 30)},
+		{"weekend", []string{"sat", "sun"}, "09:00", at(10, 19, 8, 0), at(10, 24, 9, 0), at(10, 18, 9, 0)},
+		{"single day a week", []string{"mon"}, "10:00", at(10, 19, 9, 0), at(10, 19, 10, 0), at(10, 12, 10, 0)},
+		// Passage à l'heure d'été le 29 mars, à l'heure d'hiver le 25 octobre
+		{"daily over spring change", nil, "07:30", at(3, 28, 8, 0), at(3, 29, 7, 30), at(3, 28, 7, 30)},
+		{"daily after spring change", nil, "07:30", at(3, 29, 7, 0), at(3, 29, 7, 30), at(3, 28, 7, 30)},
+		{"weekdays over autumn change", []string{"weekdays"}, "07:30", at(10, 24, 8, 0), at(10, 26, 7, 30), at(10, 23, 7, 30)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := config.Schedule{Days: tt.days, Time: tt.time}
+			if got, ok := Next(sc, site, tt.from); !ok || !got.Equal(tt.next) {
+				t.Errorf("Next = %s, %v, want %s", got.Format(time.RFC3339), ok, tt.next.Format(time.RFC3339))
+			}
+			if got, ok := Previous(sc, site, tt.from); !ok || !got.Equal(tt.previous) {
+				t.Errorf("Previous = %s, %v, want %s", got.Format(time.RFC3339), ok, tt.previous.Format(time.RFC3339))
+			}
+		})
+	}
+
+	// Heure locale conservée de part et d'autre du changement d'heure
+	spring, _ := Next(config.Schedule{Time: "07:30"}, site, at(3, 28, 8, 0))
+	autumn, _ := Next(config.Schedule{Time: "07:30"}, site, at(10, 25, 0, 0))
+	if spring.UTC().Hour() != 5 || autumn.UTC().Hour() != 6 {
+		t.Errorf("UTC hours = %d and %d, want 5 and 6", spring.UTC().Hour(), autumn.UTC().Hour())
+	}
+
+	// Sans coordonnées, pas d'occurrence relative au soleil
+	if got, ok := Next(config.Schedule{Time: "sunset"}, site, at(10, 16, 12, 0)); ok {
+		t.Errorf("sunset without coordinates = %s", got)
+	}
+}
@@ -0,0 +1,156 @@
+package scheduler
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"rtscommander/m/internal/config"
+	"rtscommander/m/internal/controller"
+)
+
+// Réglages de la boucle du planificateur
+const (
+	// Intervalle de vérification des programmations
+	CheckInterval = time.Second
+	// Retard au-delà duquel une occurrence manquée (arrêt, coupure) n'est plus rattrapée
+	MissedGrace = 15 * time.Minute
+)
+
+// Scheduler exécute les programmations enregistrées dans la configuration
+type Scheduler struct {
+	ctrl *controller.Controller
+	stop chan struct{}
+	done chan struct{}
+
+	mu        sync.Mutex
+	locations map[string]*time.Location
+}
+
+// New crée un planificateur
+func New(ctrl *controller.Controller) *Scheduler {
+	return &Scheduler{
+		ctrl:      ctrl,
+		locations: make(map[string]*time.Location),
+	}
+}
+
+// Start démarre la boucle du planificateur en arrière-plan
+func (s *Scheduler) Start() {
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(CheckInterval)
+		defer ticker.Stop()
+
+		s.runDue(time.Now())
+		for {
+			select {
+			case now := <-ticker.C:
+				s.runDue(now)
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+
+	log.Printf("Scheduler started (%d schedule(s))", len(s.ctrl.Config().ListSchedules()))
+}
+
+// Stop arrête la boucle du planificateur
+func (s *Scheduler) Stop() {
+	close(s.stop)
+	<-s.done
+}
+
+// runDue exécute les programmations dont une occurrence n'a pas encore été traitée
+func (s *Scheduler) runDue(now time.Time) {
+	cfg := s.ctrl.Config()
+
+	for _, name := range cfg.ListSchedules() {
+		sc, exists := cfg.GetSchedule(name)
+		if !exists || sc.Disabled {
+			continue
+		}
+
+		site, err := s.site(sc)
+		if err != nil {
+			log.Printf("[schedule %s] Invalid timezone: %v", name, err)
+			continue
+		}
+
+		occurrence, ok := Previous(sc, site, now)
+		if !ok || (sc.LastRun != nil && !occurrence.After(*sc.LastRun)) {
+			continue
+		}
+
+		// L'occurrence est marquée avant l'exécution : un redémarrage pendant
+		// l'émission ne la rejoue pas
+		if err := cfg.MarkScheduleRun(name, occurrence); err != nil {
+			log.Printf("[schedule %s] Failed to record run, skipping: %v", name, err)
+			continue
+		}
+
+		if late := now.Sub(occurrence); late > MissedGrace {
+			log.Printf("[schedule %s] Occurrence of %s missed by %v, skipped", name, occurrence.Format(time.RFC3339), late.Round(time.Second))
+			continue
+		}
+
+		s.execute(sc)
+	}
+}
+
+// execute envoie la commande d'une programmation
+func (s *Scheduler) execute(sc config.Schedule) {
+	session := s.ctrl.As(&controller.Principal{Source: controller.SourceSchedule, Name: sc.Name})
+	results, err := session.Run(sc.Remote, sc.Group, sc.Command, sc.Value)
+	if err != nil {
+		log.Printf("[schedule %s] Command '%s' failed: %v", sc.Name, sc.Command, err)
+		return
+	}
+	for _, r := range results {
+		if !r.Success {
+			log.Printf("[schedule %s] Command '%s' failed for '%s': %s", sc.Name, sc.Command, r.Remote, r.Error)
+		}
+	}
+	log.Printf("[schedule %s] Command '%s' executed", sc.Name, sc.Command)
+}
+
+// site retourne le fuseau (mis en cache) et les coordonnées d'une programmation
+func (s *Scheduler) site(sc config.Schedule) (Site, error) {
+	loc, err := s.location(sc)
+	if err != nil {
+		return Site{}, err
+	}
+	site := Site{Location: loc}
+	site.Latitude, site.Longitude, site.HasCoords = s.ctrl.Config().Coordinates()
+	return site, nil
+}
+
+// location retourne le fuseau d'une programmation, à défaut celui de la
+// configuration (mis en cache)
+func (s *Scheduler) location(sc config.Schedule) (*time.Location, error) {
+	tz := sc.Timezone
+	if tz == "" {
+		tz = s.ctrl.Config().Timezone()
+	}
+	if tz == "" {
+		return time.Local, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if loc, ok := s.locations[tz]; ok {
+		return loc, nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, err
+	}
+	s.locations[tz] = loc
+	return loc, nil
+}
@@ -0,0 +1,86 @@
+package scheduler
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"rtscommander/m/internal/config"
+	"rtscommander/m/internal/controller"
+	"rtscommander/m/internal/radio"
+	"rtscommander/m/internal/remote"
+)
+
+// sentCommands compte les appuis émis par le simulateur (répétitions exclues)
+func sentCommands(sim *radio.Simulator) int {
+	count, last := 0, -1
+	for _, rec := range sim.Records() {
+		if rec.Valid && int(rec.RollingCode) != last {
+			last = int(rec.RollingCode)
+			count++
+		}
+	}
+	return count
+}
+
+func TestRunDue(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip(err)
+	}
+	occurrence := time.Date(2026, 10, 16, 7, 30, 0, 0, paris)
+	yesterday := occurrence.AddDate(0, 0, -1)
+
+	tests := []struct {
+		name    string
+		lastRun time.Time
+		now     time.Time
+		sent    int
+	}{
+		{"due", yesterday, occurrence, 1},
+		{"late within grace", yesterday, occurrence.Add(MissedGrace), 1},
+		{"missed beyond grace", yesterday, occurrence.Add(MissedGrace + time.Second), 0},
+		{"already run", occurrence, occurrence.Add(time.Minute), 0},
+		{"not yet due", yesterday, occurrence.Add(-time.Minute), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := config.Load(filepath.Join(t.TempDir(), "remotes.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer cfg.Close()
+			if err := cfg.AddRemote("salon", &remote.Control{Address: 0x123456}); err != nil {
+				t.Fatal(err)
+			}
+			if err := cfg.SetTimezone("Europe/Paris"); err != nil {
+				t.Fatal(err)
+			}
+			lastRun := tt.lastRun
+			sc := config.Schedule{Name: "salon-up", Time: "07:30", Command: "up", Remote: "salon", LastRun: &lastRun}
+			if err := cfg.AddSchedule(sc); err != nil {
+				t.Fatal(err)
+			}
+
+			sim := radio.NewSimulator(0, nil)
+			s := New(controller.New(cfg, sim))
+
+			// Un second passage n'exécute pas de nouveau l'occurrence
+			s.runDue(tt.now)
+			s.runDue(tt.now.Add(CheckInterval))
+			if got := sentCommands(sim); got != tt.sent {
+				t.Errorf("%d command(s) sent, want %d", got, tt.sent)
+			}
+
+			// Exécutée ou manquée, l'occurrence échue est marquée
+			want := yesterday
+			if !tt.now.Before(occurrence) {
+				want = occurrence
+			}
+			saved, _ := cfg.GetSchedule(sc.Name)
+			if saved.LastRun == nil || !saved.LastRun.Equal(want) {
+				t.Errorf("last run = %v, want %s", saved.LastRun, want.Format(time.RFC3339))
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package solar
+
+import (
+	"math"
+	"time"
+)
+
+// Hauteur du soleil au lever/coucher : réfraction et demi-diamètre apparent
+const horizon = -0.833
+
+// Époque J2000 et jour julien de l'époque Unix
+const (
+	j2000     = 2451545.0
+	unixEpoch = 2440587.5
+)
+
+// Sunrise retourne l'heure du lever du soleil le jour civil de date (dans le
+// fuseau de date) pour une latitude et une longitude (est positive) en degrés.
+// ok vaut false pendant la nuit ou le jour polaire.
+func Sunrise(date time.Time, latitude, longitude float64) (time.Time, bool) {
+	rise, _, ok := Times(date, latitude, longitude)
+	return rise, ok
+}
+
+// Sunset retourne l'heure du coucher du soleil, voir Sunrise
+func Sunset(date time.Time, latitude, longitude float64) (time.Time, bool) {
+	_, set, ok := Times(date, latitude, longitude)
+	return set, ok
+}
+
+// Times calcule le lever et le coucher du soleil avec l'équation du lever du
+// soleil (algorithme NOAA simplifié, précision de l'ordre de la minute)
+func Times(date time.Time, latitude, longitude float64) (rise, set time.Time, ok bool) {
+	// Jour julien à midi UTC du jour civil demandé
+	noon := time.Date(date.Year(), date.Month(), date.Day(), 12, 0, 0, 0, time.UTC)
+	n := math.Round(julian(noon) - j2000 + 0.0008)
+
+	// Midi solaire moyen
+	meanNoon := n - longitude/360
+
+	// Anomalie moyenne, équation du centre et longitude écliptique
+	m := normalize(357.5291 + 0.98560028*meanNoon)
+	c := 1.9148*sin(m) + 0.02*sin(2*m) + 0.0003*sin(3*m)
+	lambda := normalize(m + c + 180 + 102.9372)
+
+	// Transit solaire et déclinaison
+	transit := j2000 + meanNoon + 0.0053*sin(m) - 0.0069*sin(2*lambda)
+	sinDecl := sin(lambda) * sin(23.4397)
+	cosDecl := math.Cos(math.Asin(sinDecl))
+
+	// Angle horaire du lever/coucher
+	cosOmega := (sin(horizon) - sin(latitude)*sinDecl) / (cos(latitude) * cosDecl)
+	if cosOmega < -1 || cosOmega > 1 {
+		return time.Time{}, time.Time{}, false
+	}
+	omega := math.Acos(cosOmega) * 180 / math.Pi
+
+	loc := date.Location()
+	return fromJulian(transit - omega/360).In(loc), fromJulian(transit + omega/360).In(loc), true
+}
+
+// julian convertit une date en jour julien
+func julian(t time.Time) float64 {
+	return float64(t.Unix())/86400 + unixEpoch
+}
+
+// fromJulian convertit un jour julien en date, arrondie à la seconde
+func fromJulian(j float64) time.Time {
+	return time.Unix(int64(math.Round((j-unixEpoch)*86400)), 0)
+}
+
+// normalize ramène un angle dans [0, 360)
+func normalize(deg float64) float64 {
+	deg = math.Mod(deg, 360)
+	if deg < 0 {
+		deg += 360
+	}
+	return deg
+}
+
+func sin(deg float64) float64 { return math.Sin(deg * math.Pi / 180) }
+func cos(deg float64) float64 { return math.Cos(deg * math.Pi / 180) }
@@ -0,0 +1,81 @@
+package solar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimes(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip(err)
+	}
+	sydney, err := time.LoadLocation("Australia/Sydney")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	// Heures publiées (éphémérides, à la minute près)
+	tests := []struct {
+		name                string
+		loc                 *time.Location
+		latitude, longitude float64
+		month               time.Month
+		rise, set           string
+	}{
+		{"Paris summer solstice", paris, 48.8566, 2.3522, time.June, "05:47", "21:58"},
+		{"Paris winter solstice", paris, 48.8566, 2.3522, time.December, "08:41", "16:56"},
+		{"Sydney winter solstice", sydney, -33.8688, 151.2093, time.June, "07:00", "16:54"},
+		{"Sydney summer solstice", sydney, -33.8688, 151.2093, time.December, "05:41", "20:05"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			date := time.Date(2026, tt.month, 21, 0, 0, 0, 0, tt.loc)
+			rise, set, ok := Times(date, tt.latitude, tt.longitude)
+			if !ok {
+				t.Fatal("no sunrise or sunset")
+			}
+			for _, e := range []struct {
+				event string
+				at    time.Time
+				want  string
+			}{{"sunrise", rise, tt.rise}, {"sunset", set, tt.set}} {
+				clock, _ := time.Parse("15:04", e.want)
+				want := time.Date(2026, tt.month, 21, clock.Hour(), clock.Minute(), 0, 0, tt.loc)
+				if diff := e.at.Sub(want).Abs(); diff > 2*time.Minute {
+					t.Errorf("%s = %s, want %s ± 2min", e.event, e.at.Format("15:04:05 MST"), e.want)
+				}
+				if e.at.Location() != tt.loc {
+					t.Errorf("%s in %s, want %s", e.event, e.at.Location(), tt.loc)
+				}
+			}
+		})
+	}
+}
+
+func TestTimesPolar(t *testing.T) {
+	// Tromsø : nuit polaire en décembre, soleil de minuit en juin, lever et
+	// coucher à l'équinoxe ; McMurdo : nuit polaire en juin
+	tests := []struct {
+		name                string
+		latitude, longitude float64
+		month               time.Month
+		ok                  bool
+	}{
+		{"polar night", 69.6492, 18.9553, time.December, false},
+		{"midnight sun", 69.6492, 18.9553, time.June, false},
+		{"southern polar night", -77.85, 166.67, time.June, false},
+		{"equinox", 69.6492, 18.9553, time.March, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rise, set, ok := Times(time.Date(2026, tt.month, 21, 0, 0, 0, 0, time.UTC), tt.latitude, tt.longitude)
+			if ok != tt.ok {
+				t.Errorf("ok = %v, want %v (sunrise %s, sunset %s)", ok, tt.ok, rise, set)
+			}
+			if !ok && (!rise.IsZero() || !set.IsZero()) {
+				t.Errorf("times returned without sunrise or sunset: %s, %s", rise, set)
+			}
+		})
+	}
+}
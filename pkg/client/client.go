@@ -0,0 +1,395 @@
+// Package client est un client Go typé de l'API HTTP de rtsCommander (routes
+// /api/v1 décrites par /openapi.json).
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// En-tête portant la phrase secrète d'une sauvegarde chiffrée
+const passphraseHeader = "X-Backup-Passphrase"
+
+// Error représente une erreur renvoyée par l'API
+type Error struct {
+	StatusCode int
+	Message    string
+	Response   *CommandResponse // Corps de l'erreur (résultats par membre d'un groupe)
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (HTTP %d)", e.Message, e.StatusCode)
+}
+
+// IsNotFound indique si err signale une ressource inexistante
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// Client appelle l'API d'un serveur rtsCommander
+type Client struct {
+	BaseURL    string // URL du serveur, ex. "http://raspberrypi:8080"
+	Token      string // Jeton d'API (optionnel si le serveur n'en exige pas)
+	HTTPClient *http.Client
+}
+
+// New crée un client pour le serveur baseURL, authentifié par token s'il est
+// renseigné
+func New(baseURL, token string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		Token:      token,
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// ListRemotes liste les télécommandes et leur état
+func (c *Client) ListRemotes(ctx context.Context) ([]RemoteState, error) {
+	var list remoteList
+	err := c.do(ctx, http.MethodGet, "/remotes", nil, &list)
+	return list.Remotes, err
+}
+
+// GetRemote retourne une télécommande et sa position estimée
+func (c *Client) GetRemote(ctx context.Context, name string) (*RemoteState, error) {
+	var state RemoteState
+	if err := c.do(ctx, http.MethodGet, "/remotes/"+url.PathEscape(name), nil, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// CreateRemote crée une télécommande ; le nom ne doit pas être déjà utilisé
+func (c *Client) CreateRemote(ctx context.Context, rc Remote) (*RemoteState, error) {
+	var state RemoteState
+	if err := c.do(ctx, http.MethodPost, "/remotes", rc, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// PutRemote crée ou remplace entièrement une télécommande, rolling code compris
+func (c *Client) PutRemote(ctx context.Context, rc Remote) (*RemoteState, error) {
+	var state RemoteState
+	if err := c.do(ctx, http.MethodPut, "/remotes/"+url.PathEscape(rc.Name), rc, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// PatchRemote modifie une partie des champs d'une télécommande, ou la renomme
+func (c *Client) PatchRemote(ctx context.Context, name string, patch RemotePatch) (*RemoteState, error) {
+	var state RemoteState
+	if err := c.do(ctx, http.MethodPatch, "/remotes/"+url.PathEscape(name), patch, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// DeleteRemote supprime une télécommande
+func (c *Client) DeleteRemote(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, "/remotes/"+url.PathEscape(name), nil, nil)
+}
+
+// SendCommand envoie une commande (nom ou alias, ou "position" avec opts.Value)
+// à une télécommande
+func (c *Client) SendCommand(ctx context.Context, remote, command string, opts CommandOptions) (*CommandResponse, error) {
+	var resp CommandResponse
+	path := "/remotes/" + url.PathEscape(remote) + "/commands/" + url.PathEscape(command)
+	if err := c.do(ctx, http.MethodPost, path, opts, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListGroups liste les groupes
+func (c *Client) ListGroups(ctx context.Context) ([]Group, error) {
+	var list groupList
+	err := c.do(ctx, http.MethodGet, "/groups", nil, &list)
+	return list.Groups, err
+}
+
+// GetGroup retourne un groupe
+func (c *Client) GetGroup(ctx context.Context, name string) (*Group, error) {
+	var group Group
+	if err := c.do(ctx, http.MethodGet, "/groups/"+url.PathEscape(name), nil, &group); err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// PutGroup crée ou remplace un groupe
+func (c *Client) PutGroup(ctx context.Context, group Group) (*Group, error) {
+	var saved Group
+	if err := c.do(ctx, http.MethodPut, "/groups/"+url.PathEscape(group.Name), group, &saved); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+// DeleteGroup supprime un groupe
+func (c *Client) DeleteGroup(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, "/groups/"+url.PathEscape(name), nil, nil)
+}
+
+// SendGroupCommand envoie une commande à tous les membres d'un groupe. Si la
+// commande échoue pour certains membres, l'erreur retournée est une *Error dont
+// Response contient le résultat de chacun.
+func (c *Client) SendGroupCommand(ctx context.Context, group, command string, opts CommandOptions) (*CommandResponse, error) {
+	var resp CommandResponse
+	path := "/groups/" + url.PathEscape(group) + "/commands/" + url.PathEscape(command)
+	if err := c.do(ctx, http.MethodPost, path, opts, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListSchedules liste les programmations
+func (c *Client) ListSchedules(ctx context.Context) ([]ScheduleInfo, error) {
+	var list scheduleList
+	err := c.do(ctx, http.MethodGet, "/schedules", nil, &list)
+	return list.Schedules, err
+}
+
+// GetSchedule retourne une programmation et sa prochaine exécution
+func (c *Client) GetSchedule(ctx context.Context, name string) (*ScheduleInfo, error) {
+	var info ScheduleInfo
+	if err := c.do(ctx, http.MethodGet, "/schedules/"+url.PathEscape(name), nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// CreateSchedule crée une programmation ; son nom est généré si req.Name est vide
+func (c *Client) CreateSchedule(ctx context.Context, req ScheduleRequest) (*ScheduleInfo, error) {
+	var info ScheduleInfo
+	if err := c.do(ctx, http.MethodPost, "/schedules", req, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// PutSchedule crée ou remplace une programmation
+func (c *Client) PutSchedule(ctx context.Context, req ScheduleRequest) (*ScheduleInfo, error) {
+	var info ScheduleInfo
+	if err := c.do(ctx, http.MethodPut, "/schedules/"+url.PathEscape(req.Name), req, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// DeleteSchedule supprime une programmation
+func (c *Client) DeleteSchedule(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, "/schedules/"+url.PathEscape(name), nil, nil)
+}
+
+// History interroge le journal d'audit des commandes, de la plus ancienne à la
+// plus récente
+func (c *Client) History(ctx context.Context, filter HistoryFilter) ([]HistoryEntry, error) {
+	query := url.Values{}
+	for name, value := range map[string]string{"remote": filter.Remote, "source": filter.Source, "principal": filter.Principal} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	if !filter.Since.IsZero() {
+		query.Set("since", filter.Since.Format(time.RFC3339))
+	}
+	if !filter.Until.IsZero() {
+		query.Set("until", filter.Until.Format(time.RFC3339))
+	}
+	if filter.Limit > 0 {
+		query.Set("limit", strconv.Itoa(filter.Limit))
+	}
+
+	path := "/history"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	var list history
+	err := c.do(ctx, http.MethodGet, path, nil, &list)
+	return list.Entries, err
+}
+
+// Metrics retourne les compteurs de fonctionnement du serveur
+func (c *Client) Metrics(ctx context.Context) (*Metrics, error) {
+	var metrics Metrics
+	if err := c.do(ctx, http.MethodGet, "/metrics", nil, &metrics); err != nil {
+		return nil, err
+	}
+	return &metrics, nil
+}
+
+// Backup exporte la configuration du serveur dans une archive signée, chiffrée
+// si passphrase n'est pas vide (portée admin)
+func (c *Client) Backup(ctx context.Context, passphrase string) ([]byte, error) {
+	req, err := c.request(ctx, http.MethodGet, "/backup", nil)
+	if err != nil {
+		return nil, err
+	}
+	if passphrase != "" {
+		req.Header.Set(passphraseHeader, passphrase)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Restore restaure une archive sur le serveur. Sans force, une archive dont un
+// rolling code reculerait est refusée (*Error de statut 409).
+func (c *Client) Restore(ctx context.Context, archive []byte, passphrase string, force bool) (*RestoreReport, error) {
+	path := "/backup"
+	if force {
+		path += "?force=true"
+	}
+	req, err := c.request(ctx, http.MethodPost, path, json.RawMessage(archive))
+	if err != nil {
+		return nil, err
+	}
+	if passphrase != "" {
+		req.Header.Set(passphraseHeader, passphrase)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp)
+	}
+	var report RestoreReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %v", err)
+	}
+	return &report, nil
+}
+
+// Events s'abonne au flux d'événements du serveur (tous si remote est vide).
+// Le canal est fermé à l'annulation de ctx ou à la coupure de la connexion.
+func (c *Client) Events(ctx context.Context, remote string) (<-chan Event, error) {
+	path := "/events"
+	if remote != "" {
+		path += "?remote=" + url.QueryEscape(remote)
+	}
+
+	req, err := c.request(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, decodeError(resp)
+	}
+
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		defer resp.Body.Close()
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+			if !ok {
+				continue
+			}
+			var ev Event
+			if err := json.Unmarshal([]byte(data), &ev); err != nil {
+				continue
+			}
+			select {
+			case events <- ev:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+// request prépare une requête vers une route /api/v1
+func (c *Client) request(ctx context.Context, method, path string, body interface{}) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+"/api/v1"+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	return req, nil
+}
+
+// do exécute une requête et décode la réponse JSON dans out (si non nil)
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	req, err := c.request(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return decodeError(resp)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %v", err)
+	}
+	return nil
+}
+
+// decodeError convertit une réponse d'erreur en *Error
+func decodeError(resp *http.Response) error {
+	apiErr := &Error{StatusCode: resp.StatusCode, Message: resp.Status}
+
+	var body CommandResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err == nil && body.Message != "" {
+		apiErr.Message = body.Message
+		apiErr.Response = &body
+	}
+	return apiErr
+}
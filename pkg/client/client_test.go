@@ -0,0 +1,155 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// newTestClient démarre un serveur de test servant handler et retourne un
+// client authentifié qui l'appelle
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return New(server.URL+"/", "secret")
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		message  string
+		notFound bool
+		results  int
+	}{
+		{"not found", http.StatusNotFound, `{"success": false, "message": "Remote 'salon' not found"}`, "Remote 'salon' not found", true, 0},
+		{"group failure", http.StatusBadGateway, `{"success": false, "message": "Command failed for 1 of 2 remote(s)", "group": "rdc",
+			"results": [{"remote": "salon", "success": true}, {"remote": "cuisine", "success": false, "error": "radio busy"}]}`,
+			"Command failed for 1 of 2 remote(s)", false, 2},
+		{"body without message", http.StatusInternalServerError, `oops`, "500 Internal Server Error", false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+					t.Errorf("Authorization = %q", got)
+				}
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			})
+
+			_, err := c.SendGroupCommand(context.Background(), "rdc", "up", CommandOptions{})
+			var apiErr *Error
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("err = %v, want *Error", err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.Message != tt.message {
+				t.Errorf("error = %d %q, want %d %q", apiErr.StatusCode, apiErr.Message, tt.status, tt.message)
+			}
+			if IsNotFound(err) != tt.notFound {
+				t.Errorf("IsNotFound = %v", IsNotFound(err))
+			}
+			if tt.results == 0 {
+				return
+			}
+			if apiErr.Response == nil || len(apiErr.Response.Results) != tt.results {
+				t.Fatalf("response = %+v", apiErr.Response)
+			}
+			if r := apiErr.Response.Results[1]; r.Remote != "cuisine" || r.Success || r.Error != "radio busy" {
+				t.Errorf("member result = %+v", r)
+			}
+		})
+	}
+}
+
+func TestHistoryQuery(t *testing.T) {
+	since := time.Date(2026, 10, 16, 7, 30, 0, 0, time.FixedZone("CEST", 2*3600))
+	tests := []struct {
+		name   string
+		filter HistoryFilter
+		query  url.Values
+	}{
+		{"no filter", HistoryFilter{}, url.Values{}},
+		{"all fields", HistoryFilter{Remote: "salon & cuisine", Source: "mqtt", Principal: "token:tablette", Since: since, Until: since.Add(time.Hour), Limit: 10},
+			url.Values{
+				"remote": {"salon & cuisine"}, "source": {"mqtt"}, "principal": {"token:tablette"},
+				"since": {"2026-10-16T07:30:00+02:00"}, "until": {"2026-10-16T08:30:00+02:00"}, "limit": {"10"},
+			}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/v1/history" {
+					t.Errorf("path = %s", r.URL.Path)
+				}
+				if got := r.URL.Query(); got.Encode() != tt.query.Encode() {
+					t.Errorf("query = %v, want %v", got, tt.query)
+				}
+				fmt.Fprint(w, `{"entries": [{"time": "2026-10-16T07:30:00Z", "remote": "salon", "command": "up", "source": "mqtt", "result": "ok"}]}`)
+			})
+
+			entries, err := c.History(context.Background(), tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 || entries[0].Remote != "salon" || entries[0].RollingCode != nil {
+				t.Errorf("entries = %+v", entries)
+			}
+		})
+	}
+}
+
+func TestEvents(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("remote"); got != "salon" {
+			t.Errorf("remote = %q", got)
+		}
+		if got := r.Header.Get("Accept"); got != "text/event-stream" {
+			t.Errorf("Accept = %q", got)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		// Commentaires, lignes event: et données illisibles sont ignorés
+		fmt.Fprint(w, ": keep-alive\n\n")
+		fmt.Fprint(w, "event: command\ndata: {\"type\": \"command\", \"remote\": \"salon\", \"command\": \"up\", \"rolling_code\": 42}\n\n")
+		fmt.Fprint(w, "data: {invalid\n\n")
+		fmt.Fprint(w, "data: {\"type\": \"position\", \"remote\": \"salon\", \"position\": 60, \"moving\": \"up\"}\n\n")
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	events, err := c.Events(ctx, "salon")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []Event
+	for ev := range events {
+		got = append(got, ev)
+	}
+	if len(got) != 2 {
+		t.Fatalf("events = %+v, want 2", got)
+	}
+	if got[0].Type != "command" || got[0].Command != "up" || got[0].RollingCode == nil || *got[0].RollingCode != 42 {
+		t.Errorf("first event = %+v", got[0])
+	}
+	if got[1].Type != "position" || got[1].Position == nil || *got[1].Position != 60 || got[1].Moving != "up" {
+		t.Errorf("second event = %+v", got[1])
+	}
+}
+
+func TestEventsError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"success": false, "message": "Invalid token"}`)
+	})
+	if _, err := c.Events(context.Background(), ""); err == nil || err.Error() != "Invalid token (HTTP 401)" {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,188 @@
+package client
+
+import "time"
+
+// Les types ci-dessous reprennent les schémas du document /openapi.json servi
+// par l'API. Ils ne dépendent d'aucun paquet interne du serveur.
+
+// Remote représente une télécommande virtuelle (schéma Remote). Address,
+// RollingCode et EncryptionKey ne sont transmis qu'aux jetons de portée admin :
+// ils restent nuls pour les autres, voir RemoteState.Redacted.
+type Remote struct {
+	Name          string `json:"name"`
+	Address       uint32 `json:"address"`
+	RollingCode   uint16 `json:"rolling_code"`
+	EncryptionKey byte   `json:"encryption_key"`
+	TravelUpMs    int    `json:"travel_up_ms,omitempty"`
+	TravelDownMs  int    `json:"travel_down_ms,omitempty"`
+	MyPosition    *int   `json:"my_position,omitempty"`
+	Position      *int   `json:"position,omitempty"`
+}
+
+// RemoteState représente une télécommande et la position estimée de son volet
+// (schéma RemoteState)
+type RemoteState struct {
+	Remote
+	Moving   string `json:"moving,omitempty"`   // "up", "down" ou "" à l'arrêt
+	Redacted bool   `json:"redacted,omitempty"` // Secrets d'appairage masqués (jeton sans portée admin)
+}
+
+// RemotePatch décrit une modification partielle de télécommande : seuls les
+// champs renseignés sont modifiés, Name renomme la télécommande (schéma RemotePatch)
+type RemotePatch struct {
+	Name          *string `json:"name,omitempty"`
+	Address       *uint32 `json:"address,omitempty"`
+	RollingCode   *uint16 `json:"rolling_code,omitempty"`
+	EncryptionKey *byte   `json:"encryption_key,omitempty"`
+	TravelUpMs    *int    `json:"travel_up_ms,omitempty"`
+	TravelDownMs  *int    `json:"travel_down_ms,omitempty"`
+	MyPosition    *int    `json:"my_position,omitempty"`
+	Position      *int    `json:"position,omitempty"`
+}
+
+// CommandOptions précise l'appui ou la position d'une commande (schéma CommandOptions)
+type CommandOptions struct {
+	HoldMs  int  `json:"hold_ms,omitempty"` // Durée d'appui simulée (ms)
+	Repeats int  `json:"repeats,omitempty"` // Nombre de répétitions explicite
+	Value   *int `json:"value,omitempty"`   // Position cible (commande "position")
+}
+
+// MemberResult représente le résultat d'une commande pour un membre d'un
+// groupe (schéma MemberResult)
+type MemberResult struct {
+	Remote  string `json:"remote"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+	Denied  bool   `json:"denied,omitempty"` // Refusé par la liste d'accès du jeton
+}
+
+// CommandResponse représente la réponse à une commande, et le corps de toute
+// erreur (schéma CommandResponse)
+type CommandResponse struct {
+	Success bool           `json:"success"`
+	Message string         `json:"message"`
+	Remote  string         `json:"remote,omitempty"`
+	Group   string         `json:"group,omitempty"`
+	Results []MemberResult `json:"results,omitempty"`
+}
+
+// Group représente un groupe de télécommandes (schéma Group)
+type Group struct {
+	Name    string   `json:"name"`
+	Members []string `json:"members"`
+	Remote  string   `json:"remote,omitempty"` // Télécommande de groupe
+}
+
+// Schedule représente une commande programmée (champs communs des schémas
+// ScheduleRequest et ScheduleInfo)
+type Schedule struct {
+	Name     string     `json:"name"`
+	Days     []string   `json:"days,omitempty"`
+	Time     string     `json:"time"`
+	Timezone string     `json:"timezone,omitempty"`
+	Remote   string     `json:"remote,omitempty"`
+	Group    string     `json:"group,omitempty"`
+	Command  string     `json:"command"`
+	Value    *int       `json:"value,omitempty"`
+	Disabled bool       `json:"disabled,omitempty"`
+	LastRun  *time.Time `json:"last_run,omitempty"`
+}
+
+// ScheduleRequest décrit une programmation à enregistrer, en JSON ou par une
+// expression textuelle Spec (ex. "weekdays 07:30 up salon") (schéma ScheduleRequest)
+type ScheduleRequest struct {
+	Schedule
+	Spec string `json:"spec,omitempty"`
+}
+
+// ScheduleInfo représente une programmation et sa prochaine exécution (schéma ScheduleInfo)
+type ScheduleInfo struct {
+	Schedule
+	NextRun *time.Time `json:"next_run,omitempty"`
+}
+
+// Event représente un événement du flux /api/v1/events (schéma Event)
+type Event struct {
+	Type        string    `json:"type"`
+	Remote      string    `json:"remote,omitempty"`
+	Time        time.Time `json:"time"`
+	Command     string    `json:"command,omitempty"`
+	Principal   string    `json:"principal,omitempty"` // Auteur de la commande, ex. "token:tablette"
+	RollingCode *uint16   `json:"rolling_code,omitempty"`
+	Position    *int      `json:"position,omitempty"`
+	Moving      string    `json:"moving,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// HistoryEntry représente une commande du journal d'audit (schéma AuditEntry)
+type HistoryEntry struct {
+	Time        time.Time `json:"time"`
+	Remote      string    `json:"remote"`
+	Command     string    `json:"command"`
+	RollingCode *uint16   `json:"rolling_code,omitempty"`
+	Source      string    `json:"source"` // cli, http, mqtt ou schedule
+	Principal   string    `json:"principal,omitempty"`
+	Address     string    `json:"address,omitempty"`
+	Result      string    `json:"result"` // ok, error ou denied
+	Error       string    `json:"error,omitempty"`
+}
+
+// HistoryFilter sélectionne des entrées du journal d'audit ; les champs vides
+// sont ignorés
+type HistoryFilter struct {
+	Remote    string
+	Source    string
+	Principal string
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+}
+
+// StorageStats compte les écritures de la configuration du serveur
+type StorageStats struct {
+	Store        string `json:"store"`         // Support de la configuration, ex. "bolt:remotes.db"
+	Writes       uint64 `json:"writes"`        // Sauvegardes
+	RollingCodes uint64 `json:"rolling_codes"` // Rolling codes émis
+	Reservations uint64 `json:"reservations"`  // Blocs de rolling codes réservés
+	WritesSaved  uint64 `json:"writes_saved"`  // Écritures évitées
+	ReserveBlock int    `json:"reserve_block"` // Taille des blocs
+}
+
+// Metrics représente les compteurs de fonctionnement du serveur
+type Metrics struct {
+	Storage StorageStats `json:"storage"`
+}
+
+// RestoreReport résume la restauration d'une sauvegarde
+type RestoreReport struct {
+	SchemaVersion int          `json:"schema_version"` // Version de la sauvegarde, avant migration
+	Remotes       int          `json:"remotes"`
+	Groups        int          `json:"groups"`
+	Schedules     int          `json:"schedules"`
+	Tokens        int          `json:"tokens"`
+	Regressions   []Regression `json:"regressions,omitempty"`
+}
+
+// Regression décrit un rolling code qui a reculé à la restauration (force)
+type Regression struct {
+	Remote   string `json:"remote"`
+	Address  uint32 `json:"address"`
+	Current  uint16 `json:"current"`
+	Restored uint16 `json:"restored"`
+}
+
+// Réponses de liste
+type (
+	remoteList struct {
+		Remotes []RemoteState `json:"remotes"`
+	}
+	groupList struct {
+		Groups []Group `json:"groups"`
+	}
+	scheduleList struct {
+		Schedules []ScheduleInfo `json:"schedules"`
+	}
+	history struct {
+		Entries []HistoryEntry `json:"entries"`
+	}
+)
@@ -9,15 +9,14 @@ echo ""
 
 # Test 1: Lister les télécommandes
 echo "📋 Test 1: Lister les télécommandes"
-curl -s "$API_URL/remotes" | jq '.'
+curl -s "$API_URL/api/v1/remotes" | jq '.'
 echo ""
 
 # Test 2: Ajouter une télécommande de test
 echo "➕ Test 2: Ajouter une télécommande de test"
-curl -s -X POST "$API_URL/remote/add" \
+curl -s -X PUT "$API_URL/api/v1/remotes/test" \
   -H "Content-Type: application/json" \
   -d '{
-    "name": "test",
     "address": 999999,
     "rolling_code": 1,
     "encryption_key": 167
@@ -26,20 +25,30 @@ echo ""
 
 # Test 3: Récupérer les détails
 echo "🔍 Test 3: Récupérer les détails de 'test'"
-curl -s "$API_URL/remote?name=test" | jq '.'
+curl -s "$API_URL/api/v1/remotes/test" | jq '.'
 echo ""
 
 # Test 4: Envoyer une commande (simulation)
 echo "📤 Test 4: Envoyer une commande UP"
+curl -s -X POST "$API_URL/api/v1/remotes/test/commands/up" | jq '.'
+echo ""
+
+# Test 5: Ancien endpoint, conservé comme alias
+echo "↩️  Test 5: Envoyer une commande MY via /command"
 curl -s -X POST "$API_URL/command" \
   -H "Content-Type: application/json" \
   -d '{
     "remote": "test",
-    "command": "up"
+    "command": "my"
   }' | jq '.'
 echo ""
 
+# Test 6: Supprimer la télécommande de test
+echo "🗑️  Test 6: Supprimer 'test'"
+curl -s -o /dev/null -w "HTTP %{http_code}\n" -X DELETE "$API_URL/api/v1/remotes/test"
+echo ""
+
 echo "✅ Tests terminés"
 echo ""
 echo "💡 Pour tester avec vos vrais volets:"
-echo "   curl -X POST $API_URL/command -H 'Content-Type: application/json' -d '{\"remote\":\"salon\",\"command\":\"up\"}'"
+echo "   curl -X POST $API_URL/api/v1/remotes/salon/commands/up"